@@ -0,0 +1,159 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Alias SQL statements
+var (
+	addAlias = `
+        INSERT INTO project_aliases(Alias, ProjectId)
+        VALUES(?, ?)
+    `
+
+	removeAlias = `
+        DELETE FROM project_aliases
+        WHERE Alias = ?
+    `
+
+	clearAliases = `
+        DELETE FROM project_aliases
+        WHERE ProjectId = ?
+    `
+
+	findAlias = `
+        SELECT ProjectId FROM project_aliases
+        WHERE Alias = ?
+    `
+
+	findAliases = `
+        SELECT project_aliases.Alias, projects.Name
+        FROM project_aliases
+        JOIN projects ON projects.Id = project_aliases.ProjectId
+        ORDER BY project_aliases.Alias
+    `
+)
+
+// Alias - A short name for a project.
+type Alias struct {
+	Alias   string `json:"alias"`
+	Project string `json:"project"`
+}
+
+// aliasTarget - The ID of the project alias points to, or "" if it isn't
+// an alias.
+func (proj *Proj) aliasTarget(alias string) string {
+
+	var id string
+
+	if err := proj.db.QueryRow(findAlias, alias).Scan(&id); err != nil {
+		return ""
+	}
+
+	return id
+}
+
+// checkNotAlias - Fail if name is taken by an alias, so names and aliases
+// never clash.
+func (proj *Proj) checkNotAlias(name string) {
+
+	if proj.aliasTarget(name) != "" {
+		cliError(fmt.Errorf("%s is already an alias, remove it with proj alias rm %s first.", name, name))
+	}
+}
+
+// AliasAdd - Give a project a short name that works wherever its name does.
+// Aliases point at the project's ID, so they survive renames.
+func (proj *Proj) AliasAdd(alias, name string) {
+
+	if alias == "" {
+		cliError(errors.New("Aliases can't be empty."))
+	}
+
+	if proj.projectExists(alias) {
+		cliError(projectExistsError(alias))
+	}
+
+	proj.checkNotAlias(alias)
+
+	project := proj.LoadProject(name)
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	if _, err := proj.db.Exec(addAlias, alias, project.ID); err != nil {
+		cliError(errors.New("Failed to save alias."))
+	}
+
+	cliSuccessOut(fmt.Sprintf("%s is now an alias for %s", alias, project.Name))
+}
+
+// AliasRemove - Remove an alias, leaving its project alone.
+func (proj *Proj) AliasRemove(alias string) {
+
+	if proj.aliasTarget(alias) == "" {
+		cliError(errors.New("There's no alias called " + alias + "."))
+	}
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	if _, err := proj.db.Exec(removeAlias, alias); err != nil {
+		cliError(errors.New("Failed to remove alias."))
+	}
+
+	cliSuccessOut("Removed alias " + alias)
+}
+
+// AliasList - Print every alias and the project it points to.
+func (proj *Proj) AliasList() {
+
+	rows, err := proj.db.Query(findAliases)
+
+	if err != nil {
+		cliError(errors.New("Failed to load aliases."))
+	}
+
+	defer rows.Close()
+
+	aliases := []Alias{}
+
+	for rows.Next() {
+		var alias Alias
+
+		if err := rows.Scan(&alias.Alias, &alias.Project); err != nil {
+			cliError(errors.New("Failed to load aliases."))
+		}
+
+		aliases = append(aliases, alias)
+	}
+
+	if *output == "json" {
+		cliJSON(aliases)
+		return
+	}
+
+	w := newTable()
+
+	fmt.Fprintln(w, "ALIAS\tPROJECT")
+
+	for _, alias := range aliases {
+		fmt.Fprintf(w, "%s\t%s\n", alias.Alias, alias.Project)
+	}
+
+	w.Flush()
+}
+
+// loadAliased - Load the project alias points to.
+func (proj *Proj) loadAliased(alias string) (Project, error) {
+
+	id := proj.aliasTarget(alias)
+
+	if id == "" {
+		return Project{}, sql.ErrNoRows
+	}
+
+	return scanProject(proj.db.QueryRow(findByID, id))
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	findProjectRuns = `
+        SELECT StartedAt, DurationMs, ExitCode, Labels FROM project_runs
+        WHERE ProjectId = ? ORDER BY StartedAt
+    `
+)
+
+// projectArchive - What an archive file holds: the project, its run
+// history, and the name of its log, kept next to the file. Archives from
+// before runs and logs were kept hold only the project.
+type projectArchive struct {
+	Project `yaml:",inline"`
+
+	Runs []archivedRun `yaml:"runs,omitempty"`
+	Log  string        `yaml:"log,omitempty"`
+}
+
+// archivedRun - A run from the project's history.
+type archivedRun struct {
+	StartedAt  time.Time `yaml:"started_at"`
+	DurationMs *int64    `yaml:"duration_ms,omitempty"`
+	ExitCode   int       `yaml:"exit_code"`
+	Labels     string    `yaml:"labels,omitempty"`
+}
+
+// ArchiveProject - Export a project, with its run history, to a timestamped
+// YAML file, move its log next to it, then remove it from the database.
+func (proj *Proj) ArchiveProject(name, dir string) {
+
+	project := proj.LoadProject(name)
+
+	// Its process would be left running with no record of its pid, and its
+	// log moved from under it.
+	if project.Running() {
+		cliError(fmt.Errorf("%s is running (pid %d), stop it first with proj stop %s.", project.Name, project.Pid, project.Name))
+	}
+
+	if dir == "" {
+		dir = filepath.Join(projDir(), "archive")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		cliError(err)
+	}
+
+	archive := projectArchive{Project: project, Runs: proj.projectRuns(project)}
+	base := filepath.Join(dir, fmt.Sprintf("%s-%s", project.Name, time.Now().Format("20060102T150405")))
+
+	release := holdInterrupts()
+	defer release()
+
+	// Moved first, so the archive only names a log that's there.
+	if _, err := os.Stat(logPath(project)); err == nil {
+		if err := os.Rename(logPath(project), base+".log"); err != nil {
+			cliError(fmt.Errorf("Failed to move the log into the archive: %s", err))
+		}
+		archive.Log = filepath.Base(base + ".log")
+	}
+
+	data, err := yaml.Marshal(&archive)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	file := base + ".yml"
+
+	// Only delete once the definition is safely on disk.
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		if archive.Log != "" {
+			os.Rename(base+".log", logPath(project))
+		}
+		cliError(err)
+	}
+
+	proj.DeleteProject(project)
+
+	cliSuccessOut("Archived " + project.Name + " to " + file)
+}
+
+// projectRuns - A project's run history, oldest first.
+func (proj *Proj) projectRuns(project Project) []archivedRun {
+
+	rows, err := proj.db.Query(findProjectRuns, project.ID)
+
+	if err != nil {
+		cliError(errors.New("Failed to load run history."))
+	}
+
+	defer rows.Close()
+
+	var runs []archivedRun
+
+	for rows.Next() {
+		var run archivedRun
+		var duration sql.NullInt64
+
+		if err := rows.Scan(&run.StartedAt, &duration, &run.ExitCode, &run.Labels); err != nil {
+			cliError(errors.New("Failed to load run history."))
+		}
+
+		if duration.Valid {
+			run.DurationMs = &duration.Int64
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs
+}
+
+// UnarchiveProject - Restore a project from an archive file, with its run
+// history and log.
+func (proj *Proj) UnarchiveProject(file string) {
+
+	var archive projectArchive
+
+	data, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	if err := yaml.Unmarshal(data, &archive); err != nil {
+		cliError(err)
+	}
+
+	project := archive.Project
+
+	// Restored runs need the ID they're stored against.
+	if project.ID == "" && len(archive.Runs) > 0 {
+		cliError(errors.New(file + " has runs, but no project ID to restore them to."))
+	}
+
+	release := holdInterrupts()
+	defer release()
+
+	proj.SaveProject(project)
+	proj.restoreRuns(project, archive.Runs)
+
+	if archive.Log != "" {
+		proj.restoreLog(project, filepath.Join(filepath.Dir(file), archive.Log))
+	}
+
+	cliSuccessOut("Restored " + project.Name)
+}
+
+// restoreRuns - Add archived runs back to a project's history.
+func (proj *Proj) restoreRuns(project Project, runs []archivedRun) {
+
+	if len(runs) == 0 {
+		return
+	}
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	err := proj.inTx(func(tx *sql.Tx) error {
+		for _, run := range runs {
+			var ms interface{}
+			if run.DurationMs != nil {
+				ms = *run.DurationMs
+			}
+
+			if _, err := tx.Exec(addRun, project.ID, run.StartedAt, ms, run.ExitCode, run.Labels); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		cliWarn("Failed to restore the run history: " + err.Error())
+	}
+}
+
+// restoreLog - Move an archived log back into place, unless the project
+// has logged since, e.g. under a new project of the same name.
+func (proj *Proj) restoreLog(project Project, archived string) {
+
+	path := logPath(project)
+
+	if _, err := os.Stat(path); err == nil {
+		cliWarn(fmt.Sprintf("%s already has a log, so the archived one is left at %s", project.Name, archived))
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		cliWarn("Failed to restore the log: " + err.Error())
+		return
+	}
+
+	if err := os.Rename(archived, path); err != nil && !os.IsNotExist(err) {
+		cliWarn("Failed to restore the log: " + err.Error())
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"testing"
+)
+
+func TestArchiveRunningProject(t *testing.T) {
+
+	proj := newTestProj(t)
+
+	proj.SaveProject(Project{Name: "api", Path: t.TempDir(), Command: "sleep 30"})
+
+	sleeper := startSleeper(t)
+	proj.SetPid(proj.LoadProject("api"), sleeper.Process.Pid)
+
+	expectExit(t, func() { proj.ArchiveProject("api", t.TempDir()) })
+
+	if project := proj.LoadProject("api"); project.Pid != sleeper.Process.Pid {
+		t.Errorf("api's pid is %d, want %d still", project.Pid, sleeper.Process.Pid)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// nameOrID - The project name to act on, given either its name or, with
+// id, its ID.
+func (proj *Proj) nameOrID(name, id string, all bool) string {
+
+	if id == "" {
+		return name
+	}
+
+	if name != "" || all {
+		cliError(errors.New("Give a project name, --id or --all, only one of them."))
+	}
+
+	return proj.LoadProjectByID(id).Name
+}
+
+// selectProjects - Resolve the names a start or stop should act on. Either a
+// single name, or with all set every project, narrowed by only and skip.
+func (proj *Proj) selectProjects(name string, all bool, only, skip []string) []string {
+
+	if !all {
+		if name == "" {
+			cliError(errors.New("Give a project name, or --all."))
+		}
+
+		if len(only) > 0 || len(skip) > 0 {
+			cliError(errors.New("--only and --skip can only be used with --all."))
+		}
+
+		return []string{name}
+	}
+
+	if name != "" {
+		cliError(errors.New("Give either a project name or --all, not both."))
+	}
+
+	only = splitNames(only)
+	skip = splitNames(skip)
+
+	projects := proj.ListProjects()
+
+	known := map[string]bool{}
+	for _, project := range projects {
+		known[project.Name] = true
+	}
+
+	for _, filter := range [][]string{only, skip} {
+		for _, n := range filter {
+			if !known[n] {
+				cliError(proj.unknownProject(n))
+			}
+		}
+	}
+
+	var names []string
+
+	for _, project := range projects {
+		if len(only) > 0 && !contains(only, project.Name) {
+			continue
+		}
+
+		if contains(skip, project.Name) {
+			continue
+		}
+
+		names = append(names, project.Name)
+	}
+
+	return names
+}
+
+// splitNames - Flatten repeated and comma separated name flags.
+func splitNames(values []string) []string {
+
+	var names []string
+
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// contains - Whether list contains s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Height assumed when the terminal's can't be found.
+const defaultHeight = 24
+
+// castHeader - The first line of an asciinema v2 cast file.
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title"`
+	Env       map[string]string `json:"env"`
+}
+
+// castRecorder - Writes a run's output to a file as an asciinema cast, for
+// replay with `asciinema play` or on asciinema.org. Output is kept as it
+// was, colors and all, with when each piece of it arrived. stdout and
+// stderr are written from goroutines of their own, so writes are locked,
+// keeping each write's runes whole within an event.
+type castRecorder struct {
+	file    *os.File
+	started time.Time
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+// newCastRecorder - Create (or truncate) the cast file at path, and write
+// its header, sized to the terminal.
+func newCastRecorder(path string, project Project) *castRecorder {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		cliError(err)
+	}
+
+	file, err := os.Create(path)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	height := defaultHeight
+	if _, rows, err := term.GetSize(int(terminalOut().Fd())); err == nil && rows > 0 {
+		height = rows
+	} else if rows, err := strconv.Atoi(os.Getenv("LINES")); err == nil && rows > 0 {
+		height = rows
+	}
+
+	c := &castRecorder{file: file, started: time.Now()}
+
+	c.writeLine(castHeader{
+		Version:   2,
+		Width:     terminalWidth(),
+		Height:    height,
+		Timestamp: c.started.Unix(),
+		Title:     "proj start " + project.Name,
+		Env:       map[string]string{"SHELL": os.Getenv("SHELL"), "TERM": os.Getenv("TERM")},
+	})
+
+	return c
+}
+
+// Write - Record output as an event at the time it arrived. Line endings
+// become \r\n, as a terminal would show them.
+func (c *castRecorder) Write(p []byte) (int, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.partial = append(c.partial, p...)
+
+	// A rune split between writes waits for the rest, as events must be
+	// valid UTF-8.
+	cut := len(c.partial)
+	for i := cut - 1; i >= 0 && i >= cut-utf8.UTFMax; i-- {
+		if utf8.RuneStart(c.partial[i]) {
+			if !utf8.FullRune(c.partial[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+
+	if cut > 0 {
+		c.event(c.partial[:cut])
+		c.partial = append([]byte(nil), c.partial[cut:]...)
+	}
+
+	return len(p), nil
+}
+
+// event - Write one output event.
+func (c *castRecorder) event(data []byte) {
+
+	data = bytes.ReplaceAll(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n"))
+	elapsed := time.Since(c.started).Seconds()
+
+	c.writeLine([]interface{}{elapsed, "o", string(data)})
+}
+
+// writeLine - Write v as one line of JSON.
+func (c *castRecorder) writeLine(v interface{}) {
+
+	line, err := json.Marshal(v)
+
+	if err != nil {
+		return
+	}
+
+	c.file.Write(append(line, '\n'))
+}
+
+// Flush - Write out anything held back, e.g. when a command exits.
+func (c *castRecorder) Flush() {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.flushPartial()
+}
+
+// flushPartial - Flush, with the lock held.
+func (c *castRecorder) flushPartial() {
+
+	if len(c.partial) > 0 {
+		c.event(c.partial)
+		c.partial = nil
+	}
+}
+
+// Finish - Close the cast file.
+func (c *castRecorder) Finish() {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.flushPartial()
+
+	if err := c.file.Close(); err != nil {
+		cliWarn("Failed to write recording: " + err.Error())
+		return
+	}
+
+	cliOut("Recorded to " + c.file.Name() + ", play it with asciinema play")
+}
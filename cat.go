@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Cat - Print a project's proj.yml from its path, or with fromDB, the
+// proj.yml the database would generate, to compare against it.
+func (proj *Proj) Cat(name string, fromDB bool) {
+
+	project := proj.LoadProject(name)
+
+	if fromDB {
+		data, err := yaml.Marshal(&project)
+
+		if err != nil {
+			cliError(err)
+		}
+
+		os.Stdout.Write(data)
+		return
+	}
+
+	if project.Host != "" {
+		cliError(errors.New(project.Name + " runs on " + project.Host + ", so has no local proj.yml. Use --from-db to see its config."))
+	}
+
+	file, err := findProjectFile(project.Dir())
+
+	if os.IsNotExist(err) {
+		cliError(errors.New("There's no " + config.projectFile() + " at " + file + ". Use --from-db to see the stored config."))
+	}
+
+	data, err := readProjectData(file)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	os.Stdout.Write(data)
+}
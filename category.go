@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Category SQL statements
+var (
+	// A category's projects, and its subcategories'. Subcategories sort
+	// between "category/" and "category0", as '0' follows '/', which
+	// keeps the lookup on the index.
+	findInCategory = `
+        SELECT Name FROM projects
+        WHERE Category = ? OR (Category >= ? AND Category < ?)
+        ORDER BY Name
+    `
+)
+
+// validateCategory - Categories have at most two levels, e.g. client-acme
+// or client-acme/api, with no empty ones.
+func validateCategory(category string) error {
+
+	if category == "" {
+		return nil
+	}
+
+	parts := strings.Split(category, "/")
+
+	if len(parts) > 2 {
+		return fmt.Errorf("Invalid category %q: categories have at most two levels, like client-acme/api.", category)
+	}
+
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			return fmt.Errorf("Invalid category %q: each level needs a name.", category)
+		}
+	}
+
+	return nil
+}
+
+// inCategory - Whether a project is filed under category, directly or in
+// one of its subcategories.
+func inCategory(project Project, category string) bool {
+	return project.Category == category || strings.HasPrefix(project.Category, category+"/")
+}
+
+// categoryProjects - The names of the projects in a category and its
+// subcategories. It's an error if there are none.
+func (proj *Proj) categoryProjects(category string) []string {
+
+	category = strings.Trim(category, "/")
+
+	rows, err := proj.db.Query(findInCategory, category, category+"/", category+"0")
+
+	if err != nil {
+		cliError(errors.New("Failed to load projects."))
+	}
+
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+
+		if err := rows.Scan(&name); err != nil {
+			cliError(errors.New("Failed to load projects."))
+		}
+
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		cliError(fmt.Errorf("There are no projects in the category %s.", category))
+	}
+
+	return names
+}
+
+// groupByCategory - Projects grouped by category, in category order, with
+// uncategorised projects last. Each group keeps the projects' order.
+func groupByCategory(projects []Project) ([]string, map[string][]Project) {
+
+	groups := map[string][]Project{}
+	var categories []string
+
+	for _, project := range projects {
+		if _, ok := groups[project.Category]; !ok {
+			categories = append(categories, project.Category)
+		}
+		groups[project.Category] = append(groups[project.Category], project)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i] == "" || categories[j] == "" {
+			return categories[j] == ""
+		}
+		return categories[i] < categories[j]
+	})
+
+	return categories, groups
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Dependency - An external program projects run, and whether it's
+// installed here.
+type Dependency struct {
+	Binary    string   `json:"binary"`
+	Installed bool     `json:"installed"`
+	Path      string   `json:"path,omitempty"`
+	Projects  []string `json:"projects"`
+	Missing   []string `json:"missing_for,omitempty"`
+}
+
+// CheckDeps - Print every program any project's commands run, whether
+// it's installed, and which projects need it, to set up a new machine
+// with. Fails if any are missing.
+func (proj *Proj) CheckDeps() {
+
+	deps := checkDeps(proj.ListProjects())
+
+	missing := 0
+	for _, dep := range deps {
+		if !dep.Installed {
+			missing++
+		}
+	}
+
+	if *output == "json" {
+		cliJSON(deps)
+	} else {
+		w := newTable()
+
+		fmt.Fprintln(w, "BINARY\tINSTALLED\tPROJECTS")
+
+		for _, dep := range deps {
+			installed := dep.Path
+			if !dep.Installed {
+				installed = "no"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\n", dep.Binary, installed, strings.Join(dep.Projects, ", "))
+		}
+
+		w.Flush()
+	}
+
+	if missing > 0 {
+		cliError(fmt.Errorf("%d program(s) missing.", missing))
+	}
+}
+
+// checkDeps - The programs projects' start, tear down, verify and script
+// commands run, sorted by name. Each is looked up on the PATH its project
+// runs with. Remote projects are left out, as their programs live on
+// another machine, as are relative paths, which belong to the project.
+func checkDeps(projects []Project) []Dependency {
+
+	found := map[string]*Dependency{}
+
+	for _, project := range projects {
+		if project.Host != "" {
+			continue
+		}
+
+		path := getEnv(project.Environ(), "PATH")
+		seen := map[string]bool{}
+
+		for _, bin := range projectBinaries(project) {
+			if seen[bin] {
+				continue
+			}
+			seen[bin] = true
+
+			dep := found[bin]
+			if dep == nil {
+				dep = &Dependency{Binary: bin}
+				found[bin] = dep
+			}
+
+			dep.Projects = append(dep.Projects, project.Name)
+
+			file, err := lookPath(bin, project.Dir(), path)
+
+			if err != nil {
+				dep.Missing = append(dep.Missing, project.Name)
+			} else if dep.Path == "" {
+				dep.Path = file
+			}
+		}
+	}
+
+	deps := make([]Dependency, 0, len(found))
+
+	for _, dep := range found {
+		dep.Installed = len(dep.Missing) == 0
+		sort.Strings(dep.Projects)
+		sort.Strings(dep.Missing)
+		deps = append(deps, *dep)
+	}
+
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Binary < deps[j].Binary })
+
+	return deps
+}
+
+// projectBinaries - The first program each of the project's commands runs,
+// leaving out shell builtins and relative paths.
+func projectBinaries(project Project) []string {
+
+	commands := project.StartCommands()
+
+	for _, command := range []string{project.TearDown, project.VerifyCommand} {
+		if command != "" {
+			commands = append(commands, command)
+		}
+	}
+
+	for _, command := range project.Scripts {
+		commands = append(commands, command)
+	}
+
+	var binaries []string
+
+	for _, command := range commands {
+		bin := commandBinary(command)
+
+		if project.Mode == "exec" {
+			if words, err := splitWords(command); err == nil && len(words) > 0 {
+				bin = words[0]
+			}
+		}
+
+		if bin == "" || shellBuiltins[bin] || (strings.Contains(bin, "/") && !strings.HasPrefix(bin, "/")) {
+			continue
+		}
+
+		binaries = append(binaries, bin)
+	}
+
+	return binaries
+}
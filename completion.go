@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Completion SQL statements, one query per lookup, as they run on every tab.
+var (
+	completeProjects = `
+        SELECT Name FROM projects
+        UNION
+        SELECT Alias FROM project_aliases
+        ORDER BY 1
+    `
+
+	completeTags = `
+        SELECT DISTINCT Tag FROM project_tags
+        ORDER BY Tag
+    `
+
+	// Every project's, unless one is named.
+	completeProfiles = `
+        SELECT EnvProfiles FROM projects
+        WHERE ? IN ('', Name)
+    `
+
+	completeScripts = `
+        SELECT Scripts FROM projects
+        WHERE ? IN ('', Name)
+    `
+)
+
+// Values completed for flags, by flag name.
+var flagCompletions = map[string]string{
+	"tag":         "tags",
+	"env-profile": "profiles",
+	"depends-on":  "projects",
+	"only":        "projects",
+	"skip":        "projects",
+}
+
+// argCompletion - The values completed for a positional argument, if any.
+func argCompletion(arg *kingpin.ArgModel) string {
+
+	switch {
+	case strings.HasPrefix(arg.Help, "Project name"):
+		return "projects"
+	case arg.Name == "tag" || arg.Name == "old":
+		return "tags"
+	case arg.Name == "script":
+		return "scripts"
+	}
+
+	return "-"
+}
+
+// CompletionList - Print the values for one kind of completion, one a line.
+func (proj *Proj) CompletionList(kind, project string) {
+
+	var values []string
+
+	switch kind {
+	case "projects":
+		values = proj.completionRows(completeProjects)
+	case "tags":
+		values = proj.completionRows(completeTags)
+	case "profiles":
+		for _, row := range proj.completionRows(completeProfiles, project) {
+			for name := range decodeProfiles(row) {
+				values = append(values, name)
+			}
+		}
+	case "scripts":
+		for _, row := range proj.completionRows(completeScripts, project) {
+			for name := range decodeMap(row) {
+				values = append(values, name)
+			}
+		}
+	}
+
+	sort.Strings(values)
+
+	for i, value := range values {
+		if i == 0 || value != values[i-1] {
+			fmt.Println(value)
+		}
+	}
+}
+
+// completionRows - The first column of each row. Errors print nothing, as
+// a failed lookup shouldn't spill into the user's prompt.
+func (proj *Proj) completionRows(query string, args ...interface{}) []string {
+
+	rows, err := proj.db.Query(query, args...)
+
+	if err != nil {
+		return nil
+	}
+
+	defer rows.Close()
+
+	var values []string
+
+	for rows.Next() {
+		var value string
+		if rows.Scan(&value) == nil {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// CompletionScript - Print a completion script for shell. It's built from
+// the command line definition, so it never falls behind. Dynamic scripts
+// also ask proj for project names, tags, env profiles and scripts as you
+// type, through the hidden --list-* flags.
+func CompletionScript(shell string, dynamic bool) {
+
+	model := app.Model()
+
+	var script strings.Builder
+
+	if shell == "zsh" {
+		script.WriteString("autoload -U +X bashcompinit && bashcompinit\n\n")
+	}
+
+	script.WriteString("_proj_spec() {\n\tsubs= flags= valued= kinds=\n\tcase $1 in\n")
+	writeSpec(&script, "", model.Commands, model.FlagGroupModel, nil, nil)
+	for _, command := range model.FlattenedCommands() {
+		if !command.Hidden {
+			writeSpec(&script, command.FullCommand, command.Commands, model.FlagGroupModel, command.FlagGroupModel, command.ArgGroupModel)
+		}
+	}
+	for _, command := range model.Commands {
+		if len(command.Commands) > 0 {
+			writeSpec(&script, command.FullCommand, command.Commands, model.FlagGroupModel, command.FlagGroupModel, nil)
+		}
+	}
+	script.WriteString("\tesac\n}\n\n")
+
+	// Arguments past the last take after it, for lists like do's projects.
+	script.WriteString("_proj_kind() {\n\tlocal all=($kinds)\n\tkind=\n\t((${#all[@]})) && kind=${all[$1]:-${all[${#all[@]}-1]}}\n}\n\n")
+
+	script.WriteString("_proj_values() {\n")
+	if dynamic {
+		script.WriteString("\tCOMPREPLY=($(compgen -W \"$(proj completion --list-$1 --project=\"$project\" 2>/dev/null)\" -- \"$cur\"))\n")
+	} else {
+		script.WriteString("\tCOMPREPLY=()\n")
+	}
+	script.WriteString("}\n\n")
+
+	var flagCases []string
+	for name, kind := range flagCompletions {
+		flagCases = append(flagCases, fmt.Sprintf("\t\t--%s) _proj_values %s ;;\n", name, kind))
+	}
+	sort.Strings(flagCases)
+
+	script.WriteString(`_proj() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	local subs flags valued kinds path= project= flag= n=0 i w kind
+
+	_proj_spec ""
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		w=${COMP_WORDS[i]}
+		case $w in
+		=) ((i++)) ;;
+		-*)
+			if [[ " $valued " == *" $w "* ]]; then
+				((i++))
+				[[ ${COMP_WORDS[i]} == = ]] && ((i++))
+			fi
+			;;
+		*)
+			if [[ -n $subs ]]; then
+				path=${path:+$path }$w
+				_proj_spec "$path"
+			else
+				_proj_kind $n
+				[[ $kind == projects && -z $project ]] && project=$w
+				((n++))
+			fi
+			;;
+		esac
+	done
+
+	# bash splits --flag=value at the =.
+	if [[ $cur == = ]]; then
+		cur= flag=$prev
+	elif [[ $prev == = ]]; then
+		flag=${COMP_WORDS[COMP_CWORD-2]}
+	elif [[ " $valued " == *" $prev "* ]]; then
+		flag=$prev
+	fi
+
+	if [[ -n $flag ]]; then
+		case $flag in
+`)
+	for _, flagCase := range flagCases {
+		script.WriteString(flagCase)
+	}
+	script.WriteString(`		*) COMPREPLY=() ;;
+		esac
+		return
+	fi
+
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+	elif [[ -n $subs ]]; then
+		COMPREPLY=($(compgen -W "$subs" -- "$cur"))
+	else
+		_proj_kind $n
+		case $kind in
+		projects | tags | profiles | scripts) _proj_values "$kind" ;;
+		*) COMPREPLY=() ;;
+		esac
+	fi
+}
+
+complete -o default -F _proj proj
+`)
+
+	fmt.Print(script.String())
+}
+
+// writeSpec - Write one command's case in _proj_spec: its subcommands, its
+// flags and the global ones, which of those take a value, and what each
+// positional argument completes to.
+func writeSpec(script *strings.Builder, name string, commands []*kingpin.CmdModel, groups ...interface{}) {
+
+	var subs, flags, valued, kinds []string
+
+	for _, command := range commands {
+		if !command.Hidden {
+			subs = append(subs, command.Name)
+		}
+	}
+
+	for _, group := range groups {
+		switch group := group.(type) {
+		case *kingpin.FlagGroupModel:
+			if group == nil {
+				continue
+			}
+			for _, flag := range group.Flags {
+				if flag.Hidden {
+					continue
+				}
+				flags = append(flags, "--"+flag.Name)
+				if !flag.IsBoolFlag() {
+					valued = append(valued, "--"+flag.Name)
+				}
+			}
+		case *kingpin.ArgGroupModel:
+			if group == nil {
+				continue
+			}
+			for _, arg := range group.Args {
+				kinds = append(kinds, argCompletion(arg))
+			}
+		}
+	}
+
+	fmt.Fprintf(script, "\t%q)\n\t\tsubs=%q\n\t\tflags=%q\n\t\tvalued=%q\n\t\tkinds=%q\n\t\t;;\n",
+		name, strings.Join(subs, " "), strings.Join(flags, " "), strings.Join(valued, " "), strings.Join(kinds, " "))
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Used when no db_path has been configured.
+const defaultDBPath = "/tmp/projects.db"
+
+// Used when no project_file has been configured.
+const defaultProjectFile = "proj.yml"
+
+// Used when no max_project_file_size has been configured. Real project
+// files are a few KB, so anything near this is a mistake.
+const defaultMaxProjectFileSize = 1 << 20
+
+// Config - Global proj settings and state, kept in ~/.proj/config.yml.
+type Config struct {
+	DBPath      string `yaml:"db_path,omitempty"`
+	ProjectRoot string `yaml:"project_root,omitempty"`
+	Shell       string `yaml:"shell,omitempty"`
+	Color       string `yaml:"color,omitempty"`
+	ProjectFile string `yaml:"project_file,omitempty"`
+
+	// Largest project file proj reads, as a size like 512K or 2M.
+	MaxProjectFileSize string `yaml:"max_project_file_size,omitempty"`
+
+	// Retention policy for detached projects' logs, applied after each
+	// start: a duration like 168h, and a size like 500M.
+	LogMaxAge  string `yaml:"log_max_age,omitempty"`
+	LogMaxSize string `yaml:"log_max_size,omitempty"`
+
+	// How command output is normalized before it's shown or logged: the
+	// charset it's transcoded to UTF-8 from, and whether CRLFs become LFs.
+	OutputCharset string `yaml:"output_charset,omitempty"`
+	OutputStripCR bool   `yaml:"output_strip_cr,omitempty"`
+
+	// Load a .env in each project's directory when it starts. Off by
+	// default, as not every .env is meant for the process.
+	AutoEnv bool `yaml:"auto_env,omitempty"`
+
+	// Name of the most recently started project.
+	LastProject string `yaml:"last_project,omitempty"`
+}
+
+// config - The global config, loaded once in main.
+var config Config
+
+// configKey - A setting that can be managed with `proj config`.
+type configKey struct {
+	name string
+	help string
+	get  func(*Config) string
+	set  func(*Config, string) error
+}
+
+// configKeys - Every setting `proj config` knows about.
+var configKeys = []configKey{
+	{
+		name: "db_path",
+		help: "Absolute path of the projects database, default " + defaultDBPath + ".",
+		get:  func(c *Config) string { return c.DBPath },
+		set: func(c *Config, v string) error {
+			if v != "" && !filepath.IsAbs(v) {
+				return errors.New("db_path must be an absolute path")
+			}
+			c.DBPath = v
+			return nil
+		},
+	},
+	{
+		name: "project_root",
+		help: "Absolute directory that relative init paths are resolved against.",
+		get:  func(c *Config) string { return c.ProjectRoot },
+		set: func(c *Config, v string) error {
+			if v != "" && !filepath.IsAbs(v) {
+				return errors.New("project_root must be an absolute path")
+			}
+			c.ProjectRoot = v
+			return nil
+		},
+	},
+	{
+		name: "shell",
+		help: "Shell commands are run with, default sh (cmd on Windows).",
+		get:  func(c *Config) string { return c.Shell },
+		set: func(c *Config, v string) error {
+			c.Shell = v
+			return nil
+		},
+	},
+	{
+		name: "color",
+		help: "Colored output: auto, always or never.",
+		get:  func(c *Config) string { return c.Color },
+		set: func(c *Config, v string) error {
+			switch v {
+			case "", "auto", "always", "never":
+				c.Color = v
+				return nil
+			}
+			return errors.New("color must be auto, always or never")
+		},
+	},
+	{
+		name: "project_file",
+		help: "Name of each project's config file, default " + defaultProjectFile + ".",
+		get:  func(c *Config) string { return c.ProjectFile },
+		set: func(c *Config, v string) error {
+			if v == "" {
+				c.ProjectFile = v
+				return nil
+			}
+			if filepath.Base(v) != v {
+				return errors.New("project_file must be a file name, not a path")
+			}
+			if ext := filepath.Ext(v); ext != ".yml" && ext != ".yaml" {
+				return errors.New("project_file must end in .yml or .yaml")
+			}
+			c.ProjectFile = v
+			return nil
+		},
+	},
+	{
+		name: "max_project_file_size",
+		help: "Largest project file proj will read, e.g. 2M, default 1M.",
+		get:  func(c *Config) string { return c.MaxProjectFileSize },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if _, err := parseMemory(v); err != nil {
+					return errors.New("max_project_file_size must be a size like 512K or 2M")
+				}
+			}
+			c.MaxProjectFileSize = v
+			return nil
+		},
+	},
+	{
+		name: "log_max_age",
+		help: "Remove logs not written to for this long after each start, e.g. 168h.",
+		get:  func(c *Config) string { return c.LogMaxAge },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if age, err := time.ParseDuration(v); err != nil || age <= 0 {
+					return errors.New("log_max_age must be a duration like 168h")
+				}
+			}
+			c.LogMaxAge = v
+			return nil
+		},
+	},
+	{
+		name: "log_max_size",
+		help: "Remove the oldest logs after each start until they take up no more than this, e.g. 500M.",
+		get:  func(c *Config) string { return c.LogMaxSize },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if _, err := parseMemory(v); err != nil {
+					return errors.New("log_max_size must be a size like 500M or 2G")
+				}
+			}
+			c.LogMaxSize = v
+			return nil
+		},
+	},
+	{
+		name: "output_charset",
+		help: "Charset command output is transcoded to UTF-8 from: latin1, windows-1252, utf-16le or utf-16be.",
+		get:  func(c *Config) string { return c.OutputCharset },
+		set: func(c *Config, v string) error {
+			charset := strings.ToLower(v)
+			if _, ok := charsetDecoders[charset]; !ok && charset != "" && charset != "utf-8" {
+				return errors.New("output_charset must be utf-8, latin1, iso-8859-1, windows-1252, cp1252, utf-16le or utf-16be")
+			}
+			c.OutputCharset = v
+			return nil
+		},
+	},
+	{
+		name: "output_strip_cr",
+		help: "Turn CRLF line endings in command output into LF: true or false.",
+		get: func(c *Config) string {
+			if c.OutputStripCR {
+				return "true"
+			}
+			return ""
+		},
+		set: func(c *Config, v string) error {
+			switch v {
+			case "", "false":
+				c.OutputStripCR = false
+				return nil
+			case "true":
+				c.OutputStripCR = true
+				return nil
+			}
+			return errors.New("output_strip_cr must be true or false")
+		},
+	},
+	{
+		name: "auto_env",
+		help: "Load a .env file in the project's directory on start, below its own env: true or false.",
+		get: func(c *Config) string {
+			if c.AutoEnv {
+				return "true"
+			}
+			return ""
+		},
+		set: func(c *Config, v string) error {
+			switch v {
+			case "", "false":
+				c.AutoEnv = false
+				return nil
+			case "true":
+				c.AutoEnv = true
+				return nil
+			}
+			return errors.New("auto_env must be true or false")
+		},
+	},
+}
+
+// findConfigKey - Look up a known config key by name.
+func findConfigKey(name string) configKey {
+
+	for _, key := range configKeys {
+		if key.name == name {
+			return key
+		}
+	}
+
+	names := make([]string, len(configKeys))
+	for i, key := range configKeys {
+		names[i] = key.name
+	}
+
+	cliError(fmt.Errorf("Unknown config key %q, expected one of: %s", name, strings.Join(names, ", ")))
+	return configKey{}
+}
+
+// ConfigGet - Print a config value.
+func ConfigGet(name string) {
+	fmt.Println(findConfigKey(name).get(&config))
+}
+
+// ConfigSet - Validate and save a config value. An empty value resets it.
+func ConfigSet(name, value string) {
+
+	if err := findConfigKey(name).set(&config, value); err != nil {
+		cliError(err)
+	}
+
+	SaveConfig(config)
+
+	cliSuccessOut("Set " + name)
+}
+
+// ConfigList - Print every known config key and its value.
+func ConfigList() {
+
+	if *output == "json" {
+		values := map[string]string{}
+		for _, key := range configKeys {
+			values[key.name] = key.get(&config)
+		}
+		cliJSON(values)
+		return
+	}
+
+	for _, key := range configKeys {
+		fmt.Printf("%s=%s\n", key.name, key.get(&config))
+	}
+}
+
+// projDir - Proj's own directory in the user's home.
+func projDir() string {
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		cliError(err)
+	}
+
+	return filepath.Join(home, ".proj")
+}
+
+// configPath - Location of the global config file.
+func configPath() string {
+	return filepath.Join(projDir(), "config.yml")
+}
+
+// LoadConfig - Load the global config, returning defaults if there isn't one yet.
+func LoadConfig() Config {
+
+	var config Config
+
+	data, err := ioutil.ReadFile(configPath())
+
+	if os.IsNotExist(err) {
+		return config
+	}
+
+	if err != nil {
+		cliError(err)
+	}
+
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		cliError(errors.New("Invalid config file " + configPath() + ": " + err.Error()))
+	}
+
+	return config
+}
+
+// SaveConfig - Write the global config.
+func SaveConfig(config Config) {
+
+	data, err := yaml.Marshal(&config)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	if err := os.MkdirAll(projDir(), 0755); err != nil {
+		cliError(err)
+	}
+
+	if err := ioutil.WriteFile(configPath(), data, 0644); err != nil {
+		cliError(err)
+	}
+}
+
+// dbPath - Where the projects database lives.
+func (c Config) dbPath() string {
+	if c.DBPath != "" {
+		return c.DBPath
+	}
+	return defaultDBPath
+}
+
+// projectFile - Name of each project's config file.
+func (c Config) projectFile() string {
+	if c.ProjectFile != "" {
+		return c.ProjectFile
+	}
+	return defaultProjectFile
+}
+
+// maxProjectFileSize - The largest project file proj reads, in bytes.
+func (c Config) maxProjectFileSize() int64 {
+	if size, err := parseMemory(c.MaxProjectFileSize); err == nil {
+		return size
+	}
+	return defaultMaxProjectFileSize
+}
+
+// readProjectData - The contents of a project file, refusing any over
+// max_project_file_size before it's read, so a huge file picked up by
+// mistake can't exhaust memory. Reading is capped too, for files whose
+// size isn't known up front, like pipes.
+func readProjectData(path string) ([]byte, error) {
+
+	limit := config.maxProjectFileSize()
+
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	tooBig := fmt.Errorf("%s is over %d bytes, too big for a project file. Raise max_project_file_size with proj config set if it really is one.", path, limit)
+
+	if info, err := f.Stat(); err == nil && info.Size() > limit {
+		return nil, tooBig
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(f, limit+1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, tooBig
+	}
+
+	return data, nil
+}
+
+// findProjectFile - The project file in dir: the configured name, then the
+// same name with the other of .yml and .yaml, then proj.yml and
+// proj.yaml, so files written before project_file was changed still work.
+// If there's none, the configured path, with an error os.IsNotExist
+// recognises.
+func findProjectFile(dir string) (string, error) {
+
+	name := config.projectFile()
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+
+	for _, candidate := range []string{name, base + ".yml", base + ".yaml", "proj.yml", "proj.yaml"} {
+		path := filepath.Join(dir, candidate)
+
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	path := filepath.Join(dir, name)
+
+	return path, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+}
+
+// shell - Shell used to run project commands.
+func (c Config) shell() string {
+	if c.Shell != "" {
+		return c.Shell
+	}
+	return defaultShell
+}
+
+// colorUnsupported - Whether the environment asks for plain output: a dumb
+// terminal, as some CI runners set, or NO_COLOR. Checked here too, so it
+// doesn't depend on the color library's version noticing.
+func colorUnsupported() bool {
+	return os.Getenv("TERM") == "dumb" || os.Getenv("NO_COLOR") != ""
+}
+
+// lastProject - Name of the most recently started project.
+func lastProject() string {
+
+	if config.LastProject == "" {
+		cliError(errors.New("No project has been started yet."))
+	}
+
+	return config.LastProject
+}
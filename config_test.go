@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestColorUnsupported(t *testing.T) {
+
+	tests := []struct {
+		term    string
+		noColor string
+		want    bool
+	}{
+		{"xterm-256color", "", false},
+		{"dumb", "", true},
+		{"xterm-256color", "1", true},
+		{"dumb", "1", true},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		t.Setenv("TERM", test.term)
+		t.Setenv("NO_COLOR", test.noColor)
+
+		if got := colorUnsupported(); got != test.want {
+			t.Errorf("colorUnsupported() with TERM=%q NO_COLOR=%q = %t, want %t", test.term, test.noColor, got, test.want)
+		}
+	}
+}
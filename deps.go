@@ -0,0 +1,299 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Deps - Print a project's dependency tree, or with all, every project's.
+// With dot, print the graph in Graphviz DOT format instead. Cycles are
+// highlighted either way.
+func (proj *Proj) Deps(name string, all, dot bool) {
+
+	if all == (name != "") {
+		cliError(errors.New("Give a project name, or --all."))
+	}
+
+	graph := proj.dependencyGraph()
+
+	var roots []string
+
+	if all {
+		roots = graphRoots(graph)
+	} else {
+		if _, ok := graph[name]; !ok {
+			cliError(proj.unknownProject(name))
+		}
+		roots = []string{name}
+	}
+
+	if dot {
+		printDot(graph, roots)
+		return
+	}
+
+	for _, root := range roots {
+		fmt.Println(root)
+		printDeps(graph, root, "", []string{root})
+	}
+}
+
+// dependencyGraph - Each project's name, mapped to the names it depends on.
+func (proj *Proj) dependencyGraph() map[string][]string {
+
+	graph := map[string][]string{}
+	for _, project := range proj.ListProjects() {
+		graph[project.Name] = project.DependsOn
+	}
+
+	return graph
+}
+
+// dependents - Every project that needs name, directly or through others,
+// each before anything it needs, so they can be stopped in that order.
+func dependents(graph map[string][]string, name string) []string {
+
+	order := stopOrder(graph, []string{name})
+
+	return order[:len(order)-1]
+}
+
+// stopOrder - names, and everything that needs them, ordered so each
+// project comes before the ones it depends on.
+func stopOrder(graph map[string][]string, names []string) []string {
+
+	needers := map[string][]string{}
+	for project, deps := range graph {
+		for _, dep := range deps {
+			needers[dep] = append(needers[dep], project)
+		}
+	}
+
+	var order []string
+	visited := map[string]bool{}
+
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+
+		visited[name] = true
+
+		needs := needers[name]
+		sort.Strings(needs)
+
+		for _, needer := range needs {
+			visit(needer)
+		}
+
+		order = append(order, name)
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+
+	return order
+}
+
+// graphRoots - Projects nothing depends on, in name order, followed by one
+// project from each cycle that can't otherwise be reached.
+func graphRoots(graph map[string][]string) []string {
+
+	names := make([]string, 0, len(graph))
+	needed := map[string]bool{}
+
+	for name, deps := range graph {
+		names = append(names, name)
+		for _, dep := range deps {
+			needed[dep] = true
+		}
+	}
+
+	sort.Strings(names)
+
+	var roots []string
+	reached := map[string]bool{}
+
+	for _, pass := range []bool{false, true} {
+		for _, name := range names {
+			// The second pass picks up whatever the first couldn't reach.
+			if reached[name] || (!pass && needed[name]) {
+				continue
+			}
+
+			roots = append(roots, name)
+			reach(graph, name, reached)
+		}
+	}
+
+	return roots
+}
+
+// reach - Mark name, and everything it depends on, as reached.
+func reach(graph map[string][]string, name string, reached map[string]bool) {
+
+	if reached[name] {
+		return
+	}
+
+	reached[name] = true
+
+	for _, dep := range graph[name] {
+		reach(graph, dep, reached)
+	}
+}
+
+// printDeps - Print the dependencies of name below it, as a tree. path is
+// every project above, so cycles can be spotted and cut short.
+func printDeps(graph map[string][]string, name, indent string, path []string) {
+
+	deps := graph[name]
+
+	for i, dep := range deps {
+		branch, next := "├── ", "│   "
+		if i == len(deps)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		line := indent + branch + dep
+
+		if contains(path, dep) {
+			color.Red(line + " (cycle)")
+			continue
+		}
+
+		if _, ok := graph[dep]; !ok {
+			color.Yellow(line + " (unknown project)")
+			continue
+		}
+
+		fmt.Println(line)
+
+		printDeps(graph, dep, indent+next, append(append([]string{}, path...), dep))
+	}
+}
+
+// printDot - Print the part of the graph reachable from roots as Graphviz
+// DOT, with the edges of cycles in red.
+func printDot(graph map[string][]string, roots []string) {
+
+	reached := map[string]bool{}
+	for _, root := range roots {
+		reach(graph, root, reached)
+	}
+
+	names := make([]string, 0, len(reached))
+	for name := range reached {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("digraph proj {")
+
+	for _, name := range names {
+		fmt.Printf("  %q;\n", name)
+	}
+
+	for _, name := range names {
+		for _, dep := range graph[name] {
+			// An edge is part of a cycle if it leads back to where it started.
+			back := map[string]bool{}
+			reach(graph, dep, back)
+
+			if back[name] {
+				fmt.Printf("  %q -> %q [color=red];\n", name, dep)
+			} else {
+				fmt.Printf("  %q -> %q;\n", name, dep)
+			}
+		}
+	}
+
+	fmt.Println("}")
+}
+
+// Border colors for tagged projects in list --format=dot, picked by the
+// first tag, so a tag always gets the same one.
+var dotTagColors = []string{"blue", "darkgreen", "purple", "darkorange", "brown", "deeppink", "teal", "navy"}
+
+// printProjectsDot - Print projects and the dependencies between them as a
+// Graphviz DOT graph, sorted by name so the output is stable. Running
+// projects are filled green, and those whose last start failed red. Tags
+// are listed under the name, and color the border. Cycles are drawn in
+// red, as by deps --dot.
+func printProjectsDot(projects []Project) {
+
+	sorted := append([]Project(nil), projects...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	graph := map[string][]string{}
+	for _, project := range sorted {
+		graph[project.Name] = project.DependsOn
+	}
+
+	fmt.Println("digraph proj {")
+	fmt.Println("  node [shape=box, style=filled, fillcolor=white];")
+
+	for _, project := range sorted {
+		label := project.Name
+		if len(project.Tags) > 0 {
+			label += "\n" + strings.Join(project.Tags, ", ")
+		}
+
+		attrs := []string{"label=" + dotQuote(label)}
+
+		switch {
+		case project.Running():
+			attrs = append(attrs, "fillcolor=palegreen")
+		case project.LastRunAt != nil && project.LastExitCode != 0:
+			attrs = append(attrs, "fillcolor=lightpink")
+		}
+
+		if len(project.Tags) > 0 {
+			hash := fnv.New32a()
+			hash.Write([]byte(project.Tags[0]))
+			attrs = append(attrs, "color="+dotTagColors[hash.Sum32()%uint32(len(dotTagColors))], "penwidth=2")
+		}
+
+		fmt.Printf("  %s [%s];\n", dotQuote(project.Name), strings.Join(attrs, ", "))
+	}
+
+	for _, project := range sorted {
+		deps := append([]string(nil), project.DependsOn...)
+		sort.Strings(deps)
+
+		for _, dep := range deps {
+			// Projects left out, e.g. by --tag, are left out of the edges too.
+			if _, ok := graph[dep]; !ok {
+				continue
+			}
+
+			back := map[string]bool{}
+			reach(graph, dep, back)
+
+			if back[project.Name] {
+				fmt.Printf("  %s -> %s [color=red];\n", dotQuote(project.Name), dotQuote(dep))
+			} else {
+				fmt.Printf("  %s -> %s;\n", dotQuote(project.Name), dotQuote(dep))
+			}
+		}
+	}
+
+	fmt.Println("}")
+}
+
+// dotQuote - s as a DOT string. Newlines become DOT's \n line breaks.
+func dotQuote(s string) string {
+
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+
+	return `"` + s + `"`
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// logPath - Where a detached project's output goes.
+func logPath(project Project) string {
+	return filepath.Join(projDir(), "logs", project.Name+".log")
+}
+
+// detached - A project started in the background.
+type detached struct {
+	cmd    *exec.Cmd // The project's commands.
+	writer *exec.Cmd // Timestamps their output into the log.
+	offset int64     // Where their output starts in the log.
+}
+
+// Wait - Wait for the commands to exit, and their output to be logged.
+func (d *detached) Wait() error {
+
+	err := d.cmd.Wait()
+	d.writer.Wait()
+
+	return err
+}
+
+// Release - Leave both processes to run on after proj exits.
+func (d *detached) Release() {
+	d.cmd.Process.Release()
+	d.writer.Process.Release()
+}
+
+// startDetached - Run the project's commands in the background, appending
+// their output to its log file. Returns once the process has started.
+func (proj *Proj) startDetached(project Project, commands []string) error {
+
+	d, err := proj.spawnDetached(project, commands)
+
+	if err != nil {
+		return err
+	}
+
+	pid := d.cmd.Process.Pid
+	d.Release()
+
+	cliSuccessOut(fmt.Sprintf("Started %s in the background (pid %d), logging to %s", project.Name, pid, logPath(project)))
+
+	return nil
+}
+
+// spawnDetached - Start the project's commands in their own process group,
+// with output timestamped into its log file by proj log-writer, and record
+// the process ID.
+func (proj *Proj) spawnDetached(project Project, commands []string) (*detached, error) {
+
+	if project.Running() {
+		return nil, fmt.Errorf("%s is already running (pid %d).", project.Name, project.Pid)
+	}
+
+	if project.PidFile != "" {
+		if project.Host != "" {
+			return nil, errors.New("pid_file isn't supported for remote projects.")
+		}
+
+		if err := checkPidFile(pidFilePath(project)); err != nil {
+			return nil, err
+		}
+	}
+
+	separator := " && "
+	if project.ContinueOnError {
+		separator = "; "
+	}
+
+	cmd, err := detachedCommand(project, commands, separator)
+
+	if err != nil {
+		return nil, err
+	}
+
+	path := logPath(project)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(log, "%s ==> Starting: %s\n", time.Now().Format(logTimeFormat), strings.Join(cmd.Args, " "))
+
+	offset, err := log.Seek(0, io.SeekEnd)
+	log.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	self, err := os.Executable()
+
+	if err != nil {
+		return nil, err
+	}
+
+	read, write, err := os.Pipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The children get their own copies of the pipe's ends.
+	defer read.Close()
+	defer write.Close()
+
+	cmd.Stdout = write
+	cmd.Stderr = write
+
+	// Its own process group, so Ctrl-C in this terminal doesn't reach it,
+	// and stop can signal everything it spawned.
+	newGroup(cmd)
+
+	printCommand(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// In the same group, so it's stopped along with the commands.
+	writer := exec.Command(self, "log-writer", path)
+	writer.Stdin = read
+	joinGroup(writer, cmd.Process.Pid)
+
+	if err := writer.Start(); err != nil {
+		signalGroup(cmd.Process.Pid, syscall.SIGTERM)
+		return nil, fmt.Errorf("Failed to start the log writer: %s", err)
+	}
+
+	proj.SetPid(project, cmd.Process.Pid)
+
+	if err := proj.writePidFile(project, cmd.Process.Pid); err != nil {
+		signalGroup(cmd.Process.Pid, syscall.SIGTERM)
+		proj.SetPid(project, 0)
+		return nil, fmt.Errorf("Failed to write the pid file: %s", err)
+	}
+
+	return &detached{cmd: cmd, writer: writer, offset: offset}, nil
+}
+
+// detachedCommand - One command running all of commands. One process can't
+// exec several commands, so in exec mode the shell joins them, with each
+// word quoted so they run as exec mode would.
+func detachedCommand(project Project, commands []string, separator string) (*exec.Cmd, error) {
+
+	if project.Mode != "exec" || len(commands) == 1 {
+		return projectCommand(project, strings.Join(commands, separator)), nil
+	}
+
+	quoted := make([]string, len(commands))
+
+	for i, command := range commands {
+		expanded, err := expandCommand(project, command)
+
+		if err != nil {
+			return nil, err
+		}
+
+		words, err := splitWords(expanded)
+
+		if err != nil {
+			return nil, err
+		}
+
+		quoted[i] = quoteWords(words)
+	}
+
+	project.Mode = ""
+
+	return expandedCommand(project, strings.Join(quoted, separator)), nil
+}
+
+// stopDetached - Terminate a detached project's process group, if it's still
+// running, and forget its process ID.
+func (proj *Proj) stopDetached(project Project) {
+
+	if project.Pid == 0 {
+		return
+	}
+
+	if project.Running() {
+		cliOut(fmt.Sprintf("Terminating process group %d", project.Pid))
+
+		if err := signalGroup(project.Pid, syscall.SIGTERM); err != nil {
+			cliWarn(fmt.Sprintf("Failed to terminate %d: %s", project.Pid, err))
+		}
+	}
+
+	proj.SetPid(project, 0)
+}
+
+// Running - Whether the project has a detached process that's still alive.
+func (project Project) Running() bool {
+	return project.Pid != 0 && processAlive(project.Pid)
+}
+
+// SetPid - Record the process ID of a detached start, 0 for none, which
+// also removes its pid file.
+func (proj *Proj) SetPid(project Project, pid int) {
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	if pid == 0 {
+		proj.removePidFile(project)
+	}
+
+	if _, err := proj.db.Exec(setPid, pid, project.ID); err != nil {
+		cliError(errors.New("Failed to record process ID."))
+	}
+}
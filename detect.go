@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// projectType - A kind of project, recognised by a file in its root.
+type projectType struct {
+	markers  []string
+	command  string
+	teardown string
+}
+
+// Checked in this order; the first with a marker present wins.
+var projectTypes = []projectType{
+	{[]string{"package.json"}, "npm start", "npm stop"},
+	{[]string{"Makefile"}, "make up", "make down"},
+	{[]string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}, "docker compose up -d", "docker compose down"},
+	{[]string{"go.mod"}, "go run .", ""},
+}
+
+// detectProject - Fill in the project's boot and tear down commands from
+// the type of project at its path, where they haven't been given.
+func detectProject(project Project) Project {
+
+	dir := project.Path
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+
+	dir = Project{Path: dir}.Dir()
+
+	for _, kind := range projectTypes {
+		for _, marker := range kind.markers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err != nil {
+				continue
+			}
+
+			cliOut("Detected " + marker)
+
+			if project.Command == "" {
+				project.Command = kind.command
+				cliOut("Command: " + kind.command)
+			}
+
+			if project.TearDown == "" && kind.teardown != "" {
+				project.TearDown = kind.teardown
+				cliOut("Tear down: " + kind.teardown)
+			}
+
+			return project
+		}
+	}
+
+	cliWarn("Couldn't tell what kind of project " + dir + " is.")
+
+	return project
+}
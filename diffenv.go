@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Set by the shell proj is run from, so they differ with wherever proj was
+// last run, and would only be noise.
+var shellEnv = map[string]bool{"PWD": true, "OLDPWD": true, "SHLVL": true, "_": true}
+
+// EnvChange - A variable whose value differs between proj's config and a
+// running process.
+type EnvChange struct {
+	Configured string `json:"configured"`
+	Running    string `json:"running"`
+}
+
+// EnvDiff - How a running process's environment differs from the one proj
+// would give it now. Added variables are only in the process; removed ones
+// only in the config.
+type EnvDiff struct {
+	Added   map[string]string    `json:"added"`
+	Removed map[string]string    `json:"removed"`
+	Changed map[string]EnvChange `json:"changed"`
+}
+
+// DiffEnv - Compare the environment proj would start the project with now
+// against the one its detached process was actually started with.
+func (proj *Proj) DiffEnv(name string) {
+
+	project := proj.LoadProject(name)
+
+	if project.Host != "" {
+		cliError(errors.New(project.Name + " runs on " + project.Host + ", so its process can't be inspected from here."))
+	}
+
+	if !project.Running() {
+		cliError(errors.New(project.Name + " has no running detached process to compare with."))
+	}
+
+	running, err := processEnviron(project.Pid)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	// Built the way a start would, secrets and all, so they're compared
+	// too. They're masked when printed.
+	project, _, err = project.withEnvLayers("", nil)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	project, err = project.withTimes().withSecrets()
+
+	if err != nil {
+		cliError(err)
+	}
+
+	diff := diffEnv(envMap(project.Environ()), envMap(running))
+	diff.mask()
+
+	if *output == "json" {
+		cliJSON(diff)
+		return
+	}
+
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+		cliSuccessOut(fmt.Sprintf("%s (pid %d) has the environment proj would give it.", project.Name, project.Pid))
+		return
+	}
+
+	cliOut(fmt.Sprintf("%s (pid %d) against its config now:", project.Name, project.Pid))
+
+	for _, key := range sortedKeys(diff.Added) {
+		fmt.Printf("+ %s=%s\n", key, diff.Added[key])
+	}
+
+	for _, key := range sortedKeys(diff.Removed) {
+		fmt.Printf("- %s=%s\n", key, diff.Removed[key])
+	}
+
+	changed := make([]string, 0, len(diff.Changed))
+	for key := range diff.Changed {
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+
+	for _, key := range changed {
+		change := diff.Changed[key]
+		fmt.Printf("~ %s: %s (config) -> %s (running)\n", key, change.Configured, change.Running)
+	}
+}
+
+// processEnviron - The environment a process was started with. Only Linux
+// makes that readable, through /proc.
+func processEnviron(pid int) ([]string, error) {
+
+	data, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/environ")
+
+	if os.IsNotExist(err) && runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("Reading a process's environment needs /proc, which %s doesn't have. Try ps eww %d instead.", runtime.GOOS, pid)
+	}
+
+	if os.IsPermission(err) {
+		return nil, fmt.Errorf("Not allowed to read the environment of pid %d, which may belong to another user.", pid)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var env []string
+
+	for _, kv := range bytes.Split(data, []byte{0}) {
+		if len(kv) > 0 {
+			env = append(env, string(kv))
+		}
+	}
+
+	return env, nil
+}
+
+// diffEnv - What's been added, removed and changed going from configured
+// to running.
+func diffEnv(configured, running map[string]string) EnvDiff {
+
+	diff := EnvDiff{
+		Added:   map[string]string{},
+		Removed: map[string]string{},
+		Changed: map[string]EnvChange{},
+	}
+
+	for key, value := range running {
+		want, ok := configured[key]
+
+		switch {
+		case shellEnv[key]:
+		case !ok:
+			diff.Added[key] = value
+		case want != value:
+			diff.Changed[key] = EnvChange{Configured: want, Running: value}
+		}
+	}
+
+	for key, value := range configured {
+		if _, ok := running[key]; !ok && !shellEnv[key] {
+			diff.Removed[key] = value
+		}
+	}
+
+	return diff
+}
+
+// envMap - A KEY=value environment list as a map.
+func envMap(env []string) map[string]string {
+
+	values := make(map[string]string, len(env))
+
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			values[parts[0]] = parts[1]
+		}
+	}
+
+	return values
+}
+
+// sortedKeys - A map's keys, sorted.
+func sortedKeys(values map[string]string) []string {
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// mask - Mask the values of variables that look like secrets. They still
+// show up, so you can see that they differ, but not what they are.
+func (diff EnvDiff) mask() {
+
+	const masked = "********"
+
+	for _, values := range []map[string]string{diff.Added, diff.Removed} {
+		for key := range values {
+			if secretName.MatchString(key) {
+				values[key] = masked
+			}
+		}
+	}
+
+	for key := range diff.Changed {
+		if secretName.MatchString(key) {
+			diff.Changed[key] = EnvChange{Configured: masked, Running: masked}
+		}
+	}
+}
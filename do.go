@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// scriptResult - How a script went in one project.
+type scriptResult struct {
+	project  string
+	err      error
+	duration time.Duration
+}
+
+// Do - Run a named script in each selected project, at most jobs at a time,
+// then summarise the results.
+func (proj *Proj) Do(script string, names []string, all bool, only, skip []string, jobs int) {
+
+	if jobs < 1 {
+		cliError(errors.New("--jobs must be at least 1."))
+	}
+
+	if all && len(names) > 0 {
+		cliError(errors.New("Give either project names or --all, not both."))
+	}
+
+	if !all && len(names) == 0 {
+		cliError(errors.New("Give project names, or --all."))
+	}
+
+	if !all && (len(only) > 0 || len(skip) > 0) {
+		cliError(errors.New("--only and --skip can only be used with --all."))
+	}
+
+	var projects []Project
+
+	if all {
+		// Projects without the script are left out, rather than failing.
+		for _, name := range proj.selectProjects("", true, only, skip) {
+			if project := proj.LoadProject(name); project.Scripts[script] != "" {
+				projects = append(projects, project)
+			}
+		}
+	} else {
+		for _, name := range names {
+			project := proj.LoadProject(name)
+
+			if project.Scripts[script] == "" {
+				cliError(fmt.Errorf("%s has no %s script.", name, script))
+			}
+
+			projects = append(projects, project)
+		}
+	}
+
+	if len(projects) == 0 {
+		cliError(errors.New("No projects have a " + script + " script."))
+	}
+
+	results, started := runPool(projects, jobs, func(project Project) error {
+		return proj.runCommand(project, project.Scripts[script])
+	})
+
+	summarise(results, started)
+}
+
+// runPool - Run fn for each project, at most jobs at a time, timing each.
+// Results are in the same order as projects.
+func runPool(projects []Project, jobs int, fn func(Project) error) ([]scriptResult, time.Time) {
+
+	started := time.Now()
+	results := make([]scriptResult, len(projects))
+
+	// A bounded pool of workers, so a big project set can't swamp the machine.
+	queue := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				project := projects[i]
+				begin := time.Now()
+				err := fn(project)
+				results[i] = scriptResult{project.Name, err, time.Since(begin)}
+			}
+		}()
+	}
+
+	for i := range projects {
+		queue <- i
+	}
+
+	close(queue)
+	wg.Wait()
+
+	return results, started
+}
+
+// summarise - Print how each project went, and exit with an error if any
+// of them failed.
+func summarise(results []scriptResult, started time.Time) {
+
+	failed := 0
+
+	for _, result := range results {
+		line := fmt.Sprintf("%s %s: ", cursor, result.project)
+
+		if result.err != nil {
+			failed++
+			color.Red(line + fmt.Sprintf("failed (%s) in %s", result.err, result.duration.Round(time.Millisecond)))
+		} else {
+			color.Green(line + fmt.Sprintf("passed in %s", result.duration.Round(time.Millisecond)))
+		}
+	}
+
+	summary := fmt.Sprintf("%d passed, %d failed, in %s", len(results)-failed, failed, time.Since(started).Round(time.Millisecond))
+
+	if failed > 0 {
+		cliError(errors.New(summary))
+	}
+
+	cliSuccessOut(summary)
+}
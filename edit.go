@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// EditProject - Update a project's notes or boot command, or with neither,
+// edit the whole project as YAML in $EDITOR.
+func (proj *Proj) EditProject(name, notes, command string) {
+
+	project := proj.LoadProject(name)
+
+	if notes != "" || command != "" {
+		if notes != "" {
+			project.Notes = notes
+		}
+		if command != "" {
+			project.Command = command
+		}
+		proj.UpdateProject(project)
+		cliSuccessOut("Updated " + project.Name)
+		return
+	}
+
+	edited := editYAML(project)
+
+	// The ID is how the project is found, so it can't change.
+	edited.ID = project.ID
+
+	proj.UpdateProject(edited)
+
+	cliSuccessOut("Updated " + edited.Name)
+}
+
+// editYAML - Open a project as YAML in the user's editor, returning the result.
+func editYAML(project Project) Project {
+
+	data, err := yaml.Marshal(&project)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	file, err := ioutil.TempFile("", "proj-*.yml")
+
+	if err != nil {
+		cliError(err)
+	}
+
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		cliError(err)
+	}
+
+	file.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = defaultEditor
+	}
+
+	// Through the shell, so EDITOR can carry arguments, e.g. "code --wait".
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file.Name())
+	if !posixShell(config.shell()) {
+		cmd = shellCommand(config.shell(), editor+` "`+file.Name()+`"`)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		cliError(err)
+	}
+
+	data, err = ioutil.ReadFile(file.Name())
+
+	if err != nil {
+		cliError(err)
+	}
+
+	var edited Project
+
+	if err := yaml.Unmarshal(data, &edited); err != nil {
+		cliError(err)
+	}
+
+	return edited
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// How long --replace waits for a stopped process to exit before starting.
+const replaceTimeout = 5 * time.Second
+
+// Ensure - Start a project, unless its process, stopped check or port show
+// it's already running.
+func (proj *Proj) Ensure(name string, opts StartOptions) {
+
+	project := proj.LoadProject(name)
+
+	if reason := upReason(project); reason != "" {
+		cliSuccessOut(fmt.Sprintf("%s is already running, %s.", project.Name, reason))
+		return
+	}
+
+	cliOut("Starting: " + project.Name)
+	proj.StartProject(name, opts)
+}
+
+// Replace - Stop a project if it's already running, so it can be started
+// afresh. With dryRun, only say it would be stopped.
+func (proj *Proj) Replace(name string, dryRun bool) {
+
+	project := proj.LoadProject(name)
+
+	reason := upReason(project)
+
+	if reason == "" {
+		return
+	}
+
+	if dryRun {
+		cliOut(fmt.Sprintf("Would stop %s first, %s.", project.Name, reason))
+		return
+	}
+
+	cliOut(fmt.Sprintf("Stopping: %s, already running (%s)", project.Name, reason))
+	proj.StopProject(name, "")
+
+	// The new start mustn't race the old process for ports and files.
+	for deadline := time.Now().Add(replaceTimeout); project.Pid != 0 && processAlive(project.Pid); {
+		if time.Now().After(deadline) {
+			cliWarn(fmt.Sprintf("pid %d is still alive, starting anyway.", project.Pid))
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	cliSuccessOut("Stopped " + project.Name)
+}
+
+// upReason - Why the project counts as running: its detached process is
+// alive, or its stopped check or port say so. "" if it isn't.
+func upReason(project Project) string {
+
+	if project.Running() {
+		return fmt.Sprintf("pid %d is alive", project.Pid)
+	}
+
+	return stillUp(project)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Variable names whose values are masked when printed.
+var secretName = regexp.MustCompile(`(?i)secret|token|passw|credential|api_?key|private|auth`)
+
+// Environ - Environment the project's commands run with.
+func (project Project) Environ() []string {
+
+	var env []string
+
+	if project.CleanEnv {
+		// Even a clean environment needs PATH to find anything.
+		env = []string{"PATH=" + os.Getenv("PATH")}
+	} else {
+		env = os.Environ()
+	}
+
+	keys := make([]string, 0, len(project.Env))
+	for key := range project.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		env = setEnv(env, key, project.Env[key])
+	}
+
+	if len(project.PathPrepend) == 0 {
+		return env
+	}
+
+	dirs := make([]string, 0, len(project.PathPrepend)+1)
+
+	for _, dir := range project.PathPrepend {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(project.Dir(), dir)
+		}
+		dirs = append(dirs, dir)
+	}
+
+	dirs = append(dirs, getEnv(env, "PATH"))
+
+	return setEnv(env, "PATH", strings.Join(dirs, string(os.PathListSeparator)))
+}
+
+// Dir - The project's directory, with environment variables such as $HOME
+// or ${WORKSPACE} expanded from proj's own environment, and time variables
+// like ${date}. Undefined variables are left as written, so a missing one
+// shows up in the error rather than quietly resolving to somewhere else.
+func (project Project) Dir() string {
+
+	if project.Host != "" {
+		return expandTimes(project.Path)
+	}
+
+	return os.Expand(project.Path, func(name string) string {
+		if value, ok := timeVar(name); ok {
+			return value
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return "${" + name + "}"
+	})
+}
+
+// ResolvedEnv - The environment commands end up with. Remote projects only
+// send their own env, so that's all they get.
+func (project Project) ResolvedEnv() []string {
+
+	if project.Host == "" {
+		return project.Environ()
+	}
+
+	env := make([]string, 0, len(project.Env))
+	for key, value := range project.Env {
+		env = append(env, key+"="+value)
+	}
+
+	return env
+}
+
+// getEnv - Look up key in a KEY=value environment list.
+func getEnv(env []string, key string) string {
+
+	for _, kv := range env {
+		if strings.HasPrefix(kv, key+"=") {
+			return kv[len(key)+1:]
+		}
+	}
+
+	return ""
+}
+
+// setEnv - Set key in a KEY=value environment list, replacing any existing entry.
+func setEnv(env []string, key, value string) []string {
+
+	out := make([]string, 0, len(env)+1)
+
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, key+"=") {
+			out = append(out, kv)
+		}
+	}
+
+	return append(out, key+"="+value)
+}
+
+// printEnv - Print an environment list sorted by name, masking values of
+// variables that look like secrets.
+func printEnv(env []string) {
+
+	sorted := append([]string(nil), env...)
+	sort.Strings(sorted)
+
+	for _, kv := range sorted {
+		parts := strings.SplitN(kv, "=", 2)
+
+		if len(parts) == 2 && secretName.MatchString(parts[0]) {
+			kv = parts[0] + "=********"
+		}
+
+		fmt.Println(kv)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectDir(t *testing.T) {
+
+	t.Setenv("HOME", "/home/dev")
+	t.Setenv("WORKSPACE", "/work")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/srv/api", "/srv/api"},
+		{"$HOME/code/api", "/home/dev/code/api"},
+		{"${WORKSPACE}/api", "/work/api"},
+		{"${WORKSPACE}api", "/workapi"},
+		// Left as written, so the error shows which one's missing.
+		{"$PROJ_TEST_UNDEFINED/api", "${PROJ_TEST_UNDEFINED}/api"},
+		{"${PROJ_TEST_UNDEFINED}/api", "${PROJ_TEST_UNDEFINED}/api"},
+	}
+
+	for _, test := range tests {
+		if got := (Project{Path: test.path}).Dir(); got != test.want {
+			t.Errorf("Dir() of %s = %s, want %s", test.path, got, test.want)
+		}
+	}
+
+	// A remote project's variables are the remote shell's to expand.
+	if got := (Project{Path: "$HOME/api", Host: "box"}).Dir(); got != "$HOME/api" {
+		t.Errorf("remote Dir() = %s, want $HOME/api", got)
+	}
+}
+
+func TestResolveProjectPath(t *testing.T) {
+
+	saved := config
+	defer func() { config = saved }()
+
+	config.ProjectRoot = "/code"
+
+	tests := []struct {
+		path string
+		host string
+		want string
+	}{
+		{"/srv/api", "", "/srv/api"},
+		{"$HOME/api", "", "$HOME/api"},
+		{"${WORKSPACE}/api", "", "${WORKSPACE}/api"},
+		{"api", "", filepath.Join("/code", "api")},
+		{"api", "box", "api"},
+	}
+
+	for _, test := range tests {
+		if got := resolveProjectPath(test.path, test.host); got != test.want {
+			t.Errorf("resolveProjectPath(%s, %q) = %s, want %s", test.path, test.host, got, test.want)
+		}
+	}
+}
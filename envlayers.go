@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// envLayer - One source of env values. Later layers win.
+type envLayer struct {
+	source string
+	values map[string]string
+}
+
+// envLayers - Where the project's env comes from, lowest precedence first:
+// a .env in its directory if auto_env is on, its own env, then its env
+// file, then the profile, then --env values from the command line.
+func (project Project) envLayers(profile string, overrides map[string]string) ([]envLayer, error) {
+
+	var layers []envLayer
+
+	file := project.EnvFile
+	if file != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(project.Dir(), file)
+	}
+
+	// Remote projects' .env files are on the other machine. A .env that's
+	// also the env file is left to that layer, which takes precedence.
+	if auto := filepath.Join(project.Dir(), ".env"); config.AutoEnv && project.Host == "" && auto != file {
+		values, err := readEnvFile(auto)
+
+		if err == nil {
+			layers = append(layers, envLayer{".env", values})
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	layers = append(layers, envLayer{"env", project.Env})
+
+	if project.EnvFile != "" {
+		if project.Host != "" {
+			return nil, errors.New("env_file isn't supported for remote projects, use env.")
+		}
+
+		values, err := readEnvFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, envLayer{"env file " + project.EnvFile, values})
+	}
+
+	if profile != "" {
+		values, ok := project.EnvProfiles[profile]
+
+		if !ok {
+			return nil, fmt.Errorf("%s has no env profile called %s.", project.Name, profile)
+		}
+
+		layers = append(layers, envLayer{"profile " + profile, values})
+	}
+
+	if len(overrides) > 0 {
+		layers = append(layers, envLayer{"--env", overrides})
+	}
+
+	return layers, nil
+}
+
+// withEnvLayers - The project with its env layers merged into Env, so
+// everything after sees a single env. Returns where each value came from.
+func (project Project) withEnvLayers(profile string, overrides map[string]string) (Project, map[string]string, error) {
+
+	layers, err := project.envLayers(profile, overrides)
+
+	if err != nil {
+		return project, nil, err
+	}
+
+	env := map[string]string{}
+	sources := map[string]string{}
+
+	for _, layer := range layers {
+		for key, value := range layer.values {
+			env[key] = value
+			sources[key] = layer.source
+		}
+	}
+
+	project.Env = env
+	project.EnvFile = ""
+	project.EnvProfiles = nil
+
+	return project, sources, nil
+}
+
+// readEnvFile - Read KEY=VALUE lines from a .env style file. Blank lines
+// and # comments are skipped, a leading export is allowed, and values may
+// be wrapped in matching quotes.
+func readEnvFile(file string) (map[string]string, error) {
+
+	f, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d isn't KEY=VALUE.", file, n)
+		}
+
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+
+		values[key] = value
+	}
+
+	return values, scanner.Err()
+}
+
+// printEnvSources - Print the project's environment like printEnv, noting
+// which layer each of its variables came from. The rest are inherited from
+// proj's environment.
+func printEnvSources(project Project, sources map[string]string) {
+
+	sorted := project.ResolvedEnv()
+	sort.Strings(sorted)
+
+	for _, kv := range sorted {
+		parts := strings.SplitN(kv, "=", 2)
+
+		if len(parts) == 2 && secretName.MatchString(parts[0]) {
+			kv = parts[0] + "=********"
+		}
+
+		source, ok := sources[parts[0]]
+		switch {
+		case ok:
+		case parts[0] == "PATH" && len(project.PathPrepend) > 0:
+			source = "path_prepend"
+		default:
+			source = "environment"
+		}
+
+		fmt.Printf("%s  (%s)\n", kv, source)
+	}
+}
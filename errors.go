@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// Kinds of error, for telling them apart with errors.Is whatever their
+// message says.
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectExists   = errors.New("project already exists")
+	ErrInvalidProject  = errors.New("invalid project")
+)
+
+// What proj exits with for each kind of error, and for any other.
+const (
+	exitFailed        = 1
+	exitCommandFailed = 2
+	exitNotFound      = 3
+	exitExists        = 4
+	exitInvalid       = 5
+)
+
+// projectError - An error with a message for people, that errors.Is
+// matches against its kind.
+type projectError struct {
+	kind    error
+	message string
+}
+
+// Error - The message.
+func (e *projectError) Error() string {
+	return e.message
+}
+
+// Unwrap - The kind, for errors.Is.
+func (e *projectError) Unwrap() error {
+	return e.kind
+}
+
+// newProjectError - An error of kind, with a message formatted as by
+// fmt.Sprintf.
+func newProjectError(kind error, format string, args ...interface{}) error {
+	return &projectError{kind: kind, message: fmt.Sprintf(format, args...)}
+}
+
+// projectExistsError - The error for a name that's already taken.
+func projectExistsError(name string) error {
+	return newProjectError(ErrProjectExists, "There's already a project called %s.", name)
+}
+
+// invalidProject - err, marked as a problem with the project's settings.
+func invalidProject(err error) error {
+	return &projectError{kind: ErrInvalidProject, message: err.Error()}
+}
+
+// ErrCommandFailed - A project's command ran, but exited non-zero.
+type ErrCommandFailed struct {
+	ExitCode int
+	Err      error
+}
+
+// Error - The command's error, e.g. exit status 1.
+func (e *ErrCommandFailed) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap - The command's error, an *exec.ExitError.
+func (e *ErrCommandFailed) Unwrap() error {
+	return e.Err
+}
+
+// commandFailed - err from running a command, as an *ErrCommandFailed if
+// the command ran and exited non-zero, otherwise unchanged.
+func commandFailed(err error) error {
+
+	var exitErr *exec.ExitError
+
+	if errors.As(err, &exitErr) {
+		return &ErrCommandFailed{ExitCode: exitErr.ExitCode(), Err: err}
+	}
+
+	return err
+}
+
+// errorExitCode - What proj exits with for err.
+func errorExitCode(err error) int {
+
+	var failed *ErrCommandFailed
+
+	switch {
+	case errors.As(err, &failed):
+		return exitCommandFailed
+	case errors.Is(err, ErrProjectNotFound):
+		return exitNotFound
+	case errors.Is(err, ErrProjectExists):
+		return exitExists
+	case errors.Is(err, ErrInvalidProject):
+		return exitInvalid
+	}
+
+	return exitFailed
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+)
+
+func TestProjectErrors(t *testing.T) {
+
+	tests := []struct {
+		err  error
+		kind error
+		code int
+	}{
+		{newProjectError(ErrProjectNotFound, "Unknown project: %s.", "api"), ErrProjectNotFound, exitNotFound},
+		{projectExistsError("api"), ErrProjectExists, exitExists},
+		{invalidProject(errors.New("bad yaml")), ErrInvalidProject, exitInvalid},
+	}
+
+	for _, test := range tests {
+		if !errors.Is(test.err, test.kind) {
+			t.Errorf("errors.Is(%q, %v) = false, want true", test.err, test.kind)
+		}
+
+		// Wrapping keeps the kind.
+		if wrapped := fmt.Errorf("start: %w", test.err); !errors.Is(wrapped, test.kind) {
+			t.Errorf("errors.Is(%q, %v) = false, want true", wrapped, test.kind)
+		}
+
+		if code := errorExitCode(test.err); code != test.code {
+			t.Errorf("errorExitCode(%q) = %d, want %d", test.err, code, test.code)
+		}
+	}
+
+	if errors.Is(projectExistsError("api"), ErrProjectNotFound) {
+		t.Error("an exists error is also a not found one")
+	}
+
+	if err := invalidProject(errors.New("bad yaml")); err.Error() != "bad yaml" {
+		t.Errorf("invalidProject changed the message to %q", err)
+	}
+}
+
+func TestCommandFailed(t *testing.T) {
+
+	err := commandFailed(exec.Command("sh", "-c", "exit 3").Run())
+
+	var failed *ErrCommandFailed
+
+	if !errors.As(err, &failed) {
+		t.Fatalf("errors.As(%q, *ErrCommandFailed) = false, want true", err)
+	}
+
+	if failed.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", failed.ExitCode)
+	}
+
+	// Still an *exec.ExitError underneath, for exitCode.
+	if code := exitCode(err); code != 3 {
+		t.Errorf("exitCode = %d, want 3", code)
+	}
+
+	if code := errorExitCode(err); code != exitCommandFailed {
+		t.Errorf("errorExitCode = %d, want %d", code, exitCommandFailed)
+	}
+
+	// A command that couldn't run at all didn't fail as such.
+	err = commandFailed(exec.Command("/no/such/program").Run())
+
+	if errors.As(err, &failed) {
+		t.Errorf("errors.As(%q, *ErrCommandFailed) = true, want false", err)
+	}
+
+	if code := errorExitCode(err); code != exitFailed {
+		t.Errorf("errorExitCode = %d, want %d", code, exitFailed)
+	}
+
+	if commandFailed(nil) != nil {
+		t.Error("commandFailed(nil) isn't nil")
+	}
+}
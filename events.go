@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Event SQL statements
+var (
+	addEvent = `
+        INSERT INTO project_events(ProjectId, Project, Event, At, ExitCode, Labels)
+        VALUES(?, ?, ?, ?, ?, ?)
+    `
+
+	findEvents = `
+        SELECT Id, Project, Event, At, ExitCode, Labels FROM project_events
+        WHERE Id > ? AND At >= ?
+        ORDER BY Id
+    `
+
+	lastEventID = `
+        SELECT COALESCE(MAX(Id), 0) FROM project_events
+    `
+)
+
+// Lifecycle events, as they appear in the stream.
+const (
+	eventStarted  = "started"
+	eventStopped  = "stopped"
+	eventFailed   = "failed"
+	eventHealthOK = "health-ok"
+)
+
+// How often `proj events --follow` checks for new events.
+const eventPollInterval = time.Second
+
+// Event - One line of `proj events`. Tools parse these, so fields are only
+// ever added, never renamed or removed.
+type Event struct {
+	ID       int64     `json:"id"`
+	Event    string    `json:"event"`
+	Project  string    `json:"project"`
+	Time     time.Time `json:"time"`
+	ExitCode int       `json:"exit_code"`
+
+	// Labels the start was given with --label.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// RecordEvent - Add a lifecycle event to the stream, with the run's labels.
+func (proj *Proj) RecordEvent(project Project, event string, code int, labels map[string]string) {
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	if _, err := proj.db.Exec(addEvent, project.ID, project.Name, event, time.Now(), code, encodeLabels(labels)); err != nil {
+		cliError(errors.New("Failed to record event."))
+	}
+}
+
+// Events - Print lifecycle events as JSON lines, oldest first. With since,
+// only those from that long ago on. Following prints new events as they
+// happen, until interrupted; without since, it skips the ones before.
+func (proj *Proj) Events(since time.Duration, follow bool) {
+
+	var after int64
+	var from time.Time
+
+	if since > 0 {
+		from = time.Now().Add(-since)
+	} else if follow {
+		if err := proj.db.QueryRow(lastEventID).Scan(&after); err != nil {
+			cliError(errors.New("Failed to load events."))
+		}
+	}
+
+	for {
+		after = proj.printEvents(after, from)
+
+		if !follow {
+			return
+		}
+
+		time.Sleep(eventPollInterval)
+	}
+}
+
+// printEvents - Print the events after the one with ID after, returning
+// the last ID printed.
+func (proj *Proj) printEvents(after int64, from time.Time) int64 {
+
+	rows, err := proj.db.Query(findEvents, after, from)
+
+	if err != nil {
+		cliError(errors.New("Failed to load events."))
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var event Event
+		var labels string
+
+		if err := rows.Scan(&event.ID, &event.Project, &event.Event, &event.Time, &event.ExitCode, &labels); err != nil {
+			cliError(errors.New("Failed to load events."))
+		}
+
+		event.Labels = decodeMap(labels)
+
+		line, err := json.Marshal(event)
+
+		if err != nil {
+			cliError(err)
+		}
+
+		fmt.Println(string(line))
+		after = event.ID
+	}
+
+	return after
+}
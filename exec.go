@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// execCommand - Run command directly, without a shell. It's split into
+// words as sh would, so quotes group words, but nothing is expanded and
+// characters like | and && are passed on as they are.
+func execCommand(project Project, command string) (*exec.Cmd, error) {
+
+	args, err := splitWords(command)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) == 0 {
+		return nil, errors.New("Nothing to run in exec mode.")
+	}
+
+	// Remote commands go through the remote shell regardless, with each
+	// word quoted so it arrives as it was written.
+	if project.Host != "" {
+		return remoteCommand(project, quoteWords(args)), nil
+	}
+
+	env := project.Environ()
+
+	// Looked up on the project's PATH, which path_prepend may change,
+	// rather than proj's.
+	name := args[0]
+	if !strings.Contains(name, "/") {
+		if path, err := lookPath(name, project.Dir(), getEnv(env, "PATH")); err == nil {
+			name = path
+		}
+	}
+
+	cmd := exec.Command(name, args[1:]...)
+	cmd.Dir = project.Dir()
+	cmd.Env = env
+
+	return cmd, nil
+}
+
+// splitWords - Split s into words the way sh does: on whitespace, except
+// inside single or double quotes, with backslash escapes outside single
+// quotes.
+func splitWords(s string) ([]string, error) {
+
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("Unterminated ' in " + s)
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				// Only these are escaped inside double quotes.
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return nil, errors.New(`Unterminated " in ` + s)
+			}
+			inWord = true
+
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// quoteWords - Join words into an sh command that splits back into them.
+func quoteWords(words []string) string {
+
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = shellQuote(word)
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Keeps lines from projects running at once from interleaving.
+var stdoutMu sync.Mutex
+
+// prefixWriter - Writes whole lines to out, each prefixed with the project
+// they came from.
+type prefixWriter struct {
+	out     io.Writer
+	prefix  string
+	partial []byte
+}
+
+// Write - Print every complete line in p, holding back any partial one.
+func (w *prefixWriter) Write(p []byte) (int, error) {
+
+	w.partial = append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+
+		if i < 0 {
+			return len(p), nil
+		}
+
+		w.print(w.partial[:i+1])
+		w.partial = w.partial[i+1:]
+	}
+}
+
+// Flush - Print a partial last line.
+func (w *prefixWriter) Flush() {
+
+	if len(w.partial) > 0 {
+		w.print(append(w.partial, '\n'))
+		w.partial = nil
+	}
+}
+
+func (w *prefixWriter) print(line []byte) {
+
+	stdoutMu.Lock()
+	defer stdoutMu.Unlock()
+
+	fmt.Fprintf(w.out, "%s | %s", w.prefix, line)
+}
+
+// ExecAll - Run a shell command in every project's directory, or those with
+// tag, narrowed by only and skip, at most jobs at a time. Output is streamed
+// with each line prefixed by its project, then the results are summarised.
+func (proj *Proj) ExecAll(command, tag string, only, skip []string, jobs int) {
+
+	if jobs < 1 {
+		cliError(errors.New("--jobs must be at least 1."))
+	}
+
+	var projects []Project
+
+	for _, name := range proj.selectProjects("", true, only, skip) {
+		projects = append(projects, proj.LoadProject(name))
+	}
+
+	if tag != "" {
+		projects = withTag(projects, tag)
+	}
+
+	if len(projects) == 0 {
+		cliError(errors.New("No projects to run in."))
+	}
+
+	// Pad the prefixes, so the output lines up.
+	width := 0
+	for _, project := range projects {
+		if len(project.Name) > width {
+			width = len(project.Name)
+		}
+	}
+
+	// Shared, so a throttle applies to all of the output together.
+	stdout := streamWriter(os.Stdout)
+
+	results, started := runPool(projects, jobs, func(project Project) error {
+		out := &prefixWriter{out: stdout, prefix: fmt.Sprintf("%-*s", width, project.Name)}
+		defer out.Flush()
+
+		// The command is typed as a shell command, whatever the project's
+		// own commands use.
+		project.Mode = ""
+
+		cmd := projectCommand(project, command)
+		// One writer for both, so os/exec writes to it from one goroutine
+		// at a time.
+		normalized := outputWriter(out)
+		cmd.Stdout = normalized
+		cmd.Stderr = normalized
+		defer flush(normalized)
+
+		return cmd.Run()
+	})
+
+	flush(stdout)
+
+	summarise(results, started)
+}
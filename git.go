@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// GitInfo - The state of a project's git working copy.
+type GitInfo struct {
+	Branch string `json:"branch"`
+	Dirty  bool   `json:"dirty"`
+}
+
+// gitInfo - The branch and dirty state of the project's working copy, or
+// nil if its path isn't in a git repository. Remote projects' paths
+// aren't on this machine, so are skipped too.
+func gitInfo(project Project) *GitInfo {
+
+	if project.Host != "" {
+		return nil
+	}
+
+	dir := project.Dir()
+
+	branch, err := exec.Command("git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+
+	if err != nil {
+		return nil
+	}
+
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+
+	if err != nil {
+		return nil
+	}
+
+	return &GitInfo{
+		Branch: strings.TrimSpace(string(branch)),
+		Dirty:  len(strings.TrimSpace(string(status))) > 0,
+	}
+}
+
+// String - The branch, noting uncommitted changes, or "" outside git.
+func (info *GitInfo) String() string {
+
+	if info == nil {
+		return ""
+	}
+
+	if info.Dirty {
+		return info.Branch + " (dirty)"
+	}
+
+	return info.Branch
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// How long between readiness checks for --health-retries.
+const healthInterval = time.Second
+
+// startHealthy - Start the project detached, then check up to retries
+// times, a second apart, that its stopped check or port says it's up. The
+// process is watched in the same loop, so a crash on boot fails at once,
+// with its exit code, rather than once the retries run out. A project with
+// neither counts as ready if it's still alive after the retries.
+func (proj *Proj) startHealthy(project Project, commands []string, retries int) error {
+
+	d, err := proj.spawnDetached(project, commands)
+
+	if err != nil {
+		return err
+	}
+
+	// Waited on here, rather than released, so an early exit is noticed.
+	exited := make(chan error, 1)
+	go func() { exited <- d.Wait() }()
+
+	pid := d.cmd.Process.Pid
+	cliOut(fmt.Sprintf("Started %s in the background (pid %d), checking it's ready", project.Name, pid))
+
+	checked := project.StoppedCheck != "" || project.StoppedPort != 0
+
+	for i := 1; i <= retries; i++ {
+		select {
+		case err := <-exited:
+			proj.SetPid(project, 0)
+
+			if err == nil {
+				err = errors.New("exit status 0")
+			}
+			return fmt.Errorf("%s crashed during startup, %w. Its output is in %s", project.Name, err, logPath(project))
+
+		case <-time.After(healthInterval):
+		}
+
+		if !checked {
+			continue
+		}
+
+		if reason := stillUp(project); reason != "" {
+			cliSuccessOut(fmt.Sprintf("%s is ready, %s, logging to %s", project.Name, reason, logPath(project)))
+			return nil
+		}
+	}
+
+	if checked {
+		return fmt.Errorf("%s wasn't ready after %d checks, but is still running (pid %d). Its output is in %s", project.Name, retries, pid, logPath(project))
+	}
+
+	cliSuccessOut(fmt.Sprintf("%s is still running after %d checks (pid %d), logging to %s", project.Name, retries, pid, logPath(project)))
+
+	return nil
+}
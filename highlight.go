@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// Colors color rules can use.
+var ruleColors = map[string]color.Attribute{
+	"red":     color.FgRed,
+	"yellow":  color.FgYellow,
+	"green":   color.FgGreen,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+	"gray":    color.FgHiBlack,
+}
+
+// ColorRule - Output lines matching the regex Match are shown in Color.
+type ColorRule struct {
+	Match string `yaml:"match" json:"match"`
+	Color string `yaml:"color" json:"color"`
+}
+
+// colorRule - A ColorRule, compiled once for every line it's tried on.
+type colorRule struct {
+	match *regexp.Regexp
+	color *color.Color
+}
+
+// parseColorRule - A rule written as REGEX=COLOR, as init takes them. The
+// regex may itself contain =, so the color is after the last one.
+func parseColorRule(rule string) (ColorRule, error) {
+
+	i := strings.LastIndex(rule, "=")
+
+	if i <= 0 {
+		return ColorRule{}, fmt.Errorf("Invalid color rule %q, expected REGEX=COLOR.", rule)
+	}
+
+	return ColorRule{Match: rule[:i], Color: rule[i+1:]}, nil
+}
+
+// compileColorRules - Compile rules, checking their regexes and colors.
+func compileColorRules(rules []ColorRule) ([]colorRule, error) {
+
+	compiled := make([]colorRule, 0, len(rules))
+
+	for _, rule := range rules {
+		attribute, ok := ruleColors[strings.ToLower(rule.Color)]
+
+		if !ok {
+			names := make([]string, 0, len(ruleColors))
+			for name := range ruleColors {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			return nil, fmt.Errorf("Unknown color %q in color rule for %s, expected one of %s.", rule.Color, rule.Match, strings.Join(names, ", "))
+		}
+
+		match, err := regexp.Compile(rule.Match)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid color rule regex %s: %s", rule.Match, err)
+		}
+
+		compiled = append(compiled, colorRule{match, color.New(attribute)})
+	}
+
+	return compiled, nil
+}
+
+// highlighter - An io.Writer that colors each line by the first rule it
+// matches, and the rest with plain, if it's set.
+type highlighter struct {
+	out   io.Writer
+	rules []colorRule
+	plain *color.Color
+
+	mu   sync.Mutex
+	line []byte
+}
+
+// highlightWriter - w, with lines colored by the project's color rules,
+// if it has any.
+func highlightWriter(w io.Writer, project Project) io.Writer {
+
+	if len(project.ColorRules) == 0 {
+		return w
+	}
+
+	rules, err := compileColorRules(project.ColorRules)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	return &highlighter{out: w, rules: rules}
+}
+
+// Write - Color each complete line in p. A partial line is held until the
+// rest arrives, or Flush.
+func (h *highlighter) Write(p []byte) (int, error) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := len(p)
+
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+
+		if i < 0 {
+			h.line = append(h.line, p...)
+			break
+		}
+
+		h.line = append(h.line, p[:i]...)
+		p = p[i+1:]
+
+		if _, err := io.WriteString(h.out, h.colored(string(h.line))+"\n"); err != nil {
+			return n, err
+		}
+
+		h.line = h.line[:0]
+	}
+
+	return n, nil
+}
+
+// Flush - Pass on any partial line, then flush the writer below.
+func (h *highlighter) Flush() {
+
+	h.mu.Lock()
+
+	if len(h.line) > 0 {
+		io.WriteString(h.out, h.colored(string(h.line)))
+		h.line = h.line[:0]
+	}
+
+	h.mu.Unlock()
+
+	flush(h.out)
+}
+
+// colored - line, colored by the first rule it matches.
+func (h *highlighter) colored(line string) string {
+
+	for _, rule := range h.rules {
+		if rule.match.MatchString(line) {
+			return rule.color.Sprint(line)
+		}
+	}
+
+	if h.plain != nil {
+		return h.plain.Sprint(line)
+	}
+
+	return line
+}
+
+// encodeColorRules - Encode color rules for storage in a TEXT column, ""
+// for none.
+func encodeColorRules(rules []ColorRule) string {
+
+	if len(rules) == 0 {
+		return ""
+	}
+
+	data, _ := json.Marshal(rules)
+	return string(data)
+}
+
+// decodeColorRules - Decode color rules stored by encodeColorRules.
+func decodeColorRules(data string) []ColorRule {
+
+	var rules []ColorRule
+	json.Unmarshal([]byte(data), &rules)
+	return rules
+}
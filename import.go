@@ -0,0 +1,151 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// importEntry - A project read for import, and where it came from.
+type importEntry struct {
+	source  string
+	project Project
+}
+
+// Import - Add the projects in files to the database. Each file holds one
+// project, as in proj.yml or an archive, or a list of them, as from
+// `proj list --output=json`. With makefiles, each is a Makefile instead,
+// read by readMakefile. All of them are added, or none are: any failure
+// rolls the import back, unless partial is set, in which case the rest are
+// kept.
+func (proj *Proj) Import(files []string, partial, makefiles bool) {
+
+	read := readImportFile
+	if makefiles {
+		read = readMakefile
+	}
+
+	var entries []importEntry
+	var failures []string
+
+	for _, file := range files {
+		projects, err := read(file)
+
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", file, err))
+			continue
+		}
+
+		entries = append(entries, projects...)
+	}
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	imported := 0
+
+	// The database only sees names already added, so one file naming a
+	// project twice is caught here.
+	seen := map[string]string{}
+
+	tx, err := proj.db.Begin()
+
+	if err != nil {
+		cliError(errors.New("Failed to start import."))
+	}
+
+	for _, entry := range entries {
+		if first, ok := seen[entry.project.Name]; ok && entry.project.Name != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s is already being imported, from %s", entry.source, entry.project.Name, first))
+			continue
+		}
+
+		seen[entry.project.Name] = entry.source
+
+		if err := importProject(tx, entry.project); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", entry.source, err))
+			continue
+		}
+
+		imported++
+	}
+
+	for _, failure := range failures {
+		cliWarn(failure)
+	}
+
+	if len(failures) > 0 && !partial {
+		tx.Rollback()
+		cliError(fmt.Errorf("Nothing imported, %d problem(s). Fix them, or pass --partial to import the rest.", len(failures)))
+	}
+
+	if err := tx.Commit(); err != nil {
+		cliError(errors.New("Failed to save imported projects."))
+	}
+
+	cliSuccessOut(fmt.Sprintf("Imported %d of %d project(s)", imported, len(entries)))
+}
+
+// importProject - Check a project and add it, as part of the import's
+// transaction. A failed insert only undoes itself, not the transaction.
+func importProject(tx *sql.Tx, project Project) error {
+
+	// The same checks as adding a project one at a time.
+	if err := validateProject(project); err != nil {
+		return err
+	}
+
+	var aliased string
+	tx.QueryRow(findAlias, project.Name).Scan(&aliased)
+
+	if aliased != "" {
+		return fmt.Errorf("%s is already an alias.", project.Name)
+	}
+
+	err := insertProject(tx, project)
+
+	if duplicateName(err) {
+		return projectExistsError(project.Name)
+	}
+
+	return err
+}
+
+// readImportFile - The projects in file, labelled with the file and, for
+// lists, their position and name, so failures can be found.
+func readImportFile(file string) ([]importEntry, error) {
+
+	data, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML too, so list --output=json reads back in.
+	var projects []Project
+
+	if err := yaml.Unmarshal(data, &projects); err == nil {
+		entries := make([]importEntry, len(projects))
+
+		for i, project := range projects {
+			entries[i] = importEntry{
+				source:  strings.TrimSpace(fmt.Sprintf("%s[%d] %s", file, i, project.Name)),
+				project: project,
+			}
+		}
+
+		return entries, nil
+	}
+
+	var project Project
+
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return nil, err
+	}
+
+	return []importEntry{{source: strings.TrimSpace(file + " " + project.Name), project: project}}, nil
+}
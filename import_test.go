@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportValidates(t *testing.T) {
+
+	dir := t.TempDir()
+
+	tests := map[string]string{
+		"no command": `[{"name": "api", "path": "/srv/api"}]`,
+		"bad mode":   `[{"name": "api", "path": "/srv/api", "command": "make", "mode": "fast"}]`,
+		"bad color":  `[{"name": "api", "path": "/srv/api", "command": "make", "color_rules": [{"match": "ERROR", "color": "puce"}]}]`,
+		"duplicate":  `[{"name": "api", "path": "/srv/api", "command": "make"}, {"name": "api", "path": "/srv/web", "command": "make"}]`,
+	}
+
+	for name, data := range tests {
+		proj := newTestProj(t)
+		file := filepath.Join(dir, "projects.json")
+
+		if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		expectExit(t, func() { proj.Import([]string{file}, false, false) })
+
+		if n := countRows(t, proj); n != 0 {
+			t.Errorf("%s: got %d projects imported, want 0", name, n)
+		}
+	}
+}
+
+func TestImportPartialDuplicate(t *testing.T) {
+
+	proj := newTestProj(t)
+	file := filepath.Join(t.TempDir(), "projects.json")
+
+	data := `[{"name": "api", "path": "/srv/api", "command": "make"}, {"name": "api", "path": "/srv/web", "command": "make"}]`
+
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	proj.Import([]string{file}, true, false)
+
+	if project := proj.LoadProject("api"); project.Path != "/srv/api" {
+		t.Errorf("api has the path %s, want the first one's, /srv/api", project.Path)
+	}
+
+	if n := countRows(t, proj); n != 1 {
+		t.Errorf("got %d projects imported, want 1", n)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// readProjectFile - Read a proj.yml, merging in any files it includes.
+//
+// Included files are listed under `include`, relative to the including
+// file, and merged in order before the including file's own settings, so
+// the including file always wins. Nested maps, like env, are merged key by
+// key; anything else is replaced outright.
+func readProjectFile(path string) (Project, error) {
+
+	var project Project
+
+	merged, err := loadIncludes(path, nil)
+
+	if err != nil {
+		return project, err
+	}
+
+	data, err := yaml.Marshal(merged)
+
+	if err != nil {
+		return project, err
+	}
+
+	err = yaml.Unmarshal(data, &project)
+
+	return project, err
+}
+
+// loadIncludes - Load path as a YAML map with its includes merged in.
+// stack holds the files currently being loaded, to catch cycles.
+func loadIncludes(path string, stack []string) (map[interface{}]interface{}, error) {
+
+	abs, err := filepath.Abs(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, seen := range stack {
+		if seen == abs {
+			cycle := append(append([]string{}, stack[i:]...), abs)
+			return nil, fmt.Errorf("Cyclic include: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	stack = append(stack, abs)
+
+	data, err := readProjectData(abs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[interface{}]interface{}
+
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	includes, err := includeList(doc["include"])
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	delete(doc, "include")
+
+	merged := map[interface{}]interface{}{}
+
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(abs), include)
+		}
+
+		base, err := loadIncludes(include, stack)
+
+		if err != nil {
+			return nil, err
+		}
+
+		mergeYAML(merged, base)
+	}
+
+	mergeYAML(merged, doc)
+
+	return merged, nil
+}
+
+// includeList - Read the include directive, which is a file or list of files.
+func includeList(value interface{}) ([]string, error) {
+
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		includes := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("include entries must be file names")
+			}
+			includes = append(includes, s)
+		}
+		return includes, nil
+	}
+
+	return nil, fmt.Errorf("include must be a file name or a list of them")
+}
+
+// mergeYAML - Merge src into dst, recursing into maps present in both.
+func mergeYAML(dst, src map[interface{}]interface{}) {
+
+	for key, value := range src {
+		srcMap, srcOK := value.(map[interface{}]interface{})
+		dstMap, dstOK := dst[key].(map[interface{}]interface{})
+
+		if srcOK && dstOK {
+			mergeYAML(dstMap, srcMap)
+			continue
+		}
+
+		dst[key] = value
+	}
+}
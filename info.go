@@ -0,0 +1,65 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// Info - Where proj keeps things, and the database's schema version, for
+// bug reports.
+type Info struct {
+	Config         string `json:"config"`
+	Database       string `json:"database"`
+	Logs           string `json:"logs"`
+	SchemaVersion  int    `json:"schema_version"`
+	ExpectedSchema int    `json:"expected_schema_version"`
+	Projects       int    `json:"projects"`
+}
+
+// Info - Print where proj keeps its config, database and logs, and the
+// database's schema version against the one this proj expects.
+func (proj *Proj) Info() {
+
+	version, err := schemaVersion(proj.db)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	info := Info{
+		Config:         configPath(),
+		Database:       config.dbPath(),
+		Logs:           filepath.Join(projDir(), "logs"),
+		SchemaVersion:  version,
+		ExpectedSchema: len(migrations),
+	}
+
+	if err := proj.db.QueryRow(countProjects).Scan(&info.Projects); err != nil {
+		cliError(errors.New("Failed to count projects."))
+	}
+
+	if *output == "json" {
+		cliJSON(info)
+		return
+	}
+
+	cliOut("Config: " + info.Config)
+	cliOut("Database: " + info.Database)
+	cliOut("Logs: " + info.Logs)
+	cliOut(fmt.Sprintf("Schema version: %d (this proj expects %d)", info.SchemaVersion, info.ExpectedSchema))
+	cliOut(fmt.Sprintf("Projects: %d", info.Projects))
+}
+
+// schemaVersion - How many migrations the database has had applied.
+func schemaVersion(db *sql.DB) (int, error) {
+
+	var version int
+
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, errors.New("Failed to read schema version.")
+	}
+
+	return version, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Shared, so input that's typed ahead (or piped in) isn't lost between
+// prompts.
+var stdin = bufio.NewReader(os.Stdin)
+
+// isTerminal - Whether stdin is an interactive terminal.
+func isTerminal() bool {
+
+	info, err := os.Stdin.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ask - Prompt for a value, returning def for an empty answer. The answer is
+// asked for again until validate, if given, accepts it.
+func ask(question, def string, validate func(string) error) string {
+
+	for {
+		if def != "" {
+			fmt.Printf("%s %s [%s]: ", cursor, question, def)
+		} else {
+			fmt.Printf("%s %s: ", cursor, question)
+		}
+
+		answer, err := stdin.ReadString('\n')
+
+		if err == io.EOF && answer == "" {
+			fmt.Println()
+			cliError(errors.New("No answer given, cancelled."))
+		}
+
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			answer = def
+		}
+
+		if validate == nil {
+			return answer
+		}
+
+		if err := validate(answer); err != nil {
+			cliWarn(err.Error())
+			continue
+		}
+
+		return answer
+	}
+}
+
+// readScript - Read a boot script from stdin, keeping its newlines.
+func readScript() string {
+
+	data, err := ioutil.ReadAll(stdin)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	script := strings.TrimRight(string(data), "\n")
+
+	if strings.TrimSpace(script) == "" {
+		cliError(errors.New("No script was given on stdin."))
+	}
+
+	return script
+}
+
+// PromptProject - Walk through a new project's settings, using anything
+// already set on project as the defaults.
+func (proj *Proj) PromptProject(project Project) Project {
+
+	cwd, _ := os.Getwd()
+
+	if project.Name == "" {
+		project.Name = filepath.Base(cwd)
+	}
+
+	if project.Path == "" {
+		project.Path = cwd
+	}
+
+	project.Name = ask("Name", project.Name, func(name string) error {
+		if name == "" {
+			return errors.New("A name is required.")
+		}
+		if proj.projectExists(name) {
+			return fmt.Errorf("There's already a project called %s.", name)
+		}
+		return nil
+	})
+
+	path := ask("Path", project.Path, func(path string) error {
+		if project.Host != "" {
+			return nil
+		}
+		dir := Project{Path: resolveProjectPath(path, "")}.Dir()
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return fmt.Errorf("%s isn't a directory.", dir)
+		}
+		return nil
+	})
+	project.Path = resolveProjectPath(path, project.Host)
+
+	project.Command = ask("Boot command", project.Command, func(command string) error {
+		if command == "" {
+			return errors.New("A boot command is required.")
+		}
+		return nil
+	})
+
+	project.TearDown = ask("Tear down command (optional)", project.TearDown, nil)
+
+	tags := ask("Tags, comma separated (optional)", strings.Join(project.Tags, ","), nil)
+
+	project.Tags = nil
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = normalizeTag(tag); tag != "" {
+			project.Tags = append(project.Tags, tag)
+		}
+	}
+
+	return project
+}
+
+// projectExists - Whether a project called name has been saved.
+func (proj *Proj) projectExists(name string) bool {
+
+	_, err := scanProject(proj.db.QueryRow(find, name))
+
+	return err == nil
+}
+
+// projectIDExists - Whether a project with the ID id has been saved.
+func (proj *Proj) projectIDExists(id string) bool {
+
+	_, err := scanProject(proj.db.QueryRow(findByID, id))
+
+	return err == nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+)
+
+// Set once a start has been interrupted, so no further commands are run.
+var interrupted int32
+
+// What's sent in place of a tear down's result when there's none to run,
+// for projects saved before it was required.
+var errNoTearDown = errors.New("no tear down")
+
+// watchInterrupts - For projects with teardown_on_interrupt, catch Ctrl-C and
+// SIGTERM during a foreground start and run the tear down straight away.
+// Call the returned function once the commands have finished; if the start
+// was interrupted, it waits for the tear down, reports how it went, and
+// exits.
+func (proj *Proj) watchInterrupts(project Project) func() {
+
+	if !project.TearDownOnInterrupt {
+		return func() {}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	tornDown := make(chan error, 1)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			atomic.StoreInt32(&interrupted, 1)
+			if project.TearDown == "" {
+				cliWarn(fmt.Sprintf("Interrupted (%s), but %s has no tear down to run.", sig, project.Name))
+				tornDown <- errNoTearDown
+				return
+			}
+
+			cliWarn(fmt.Sprintf("Interrupted (%s), tearing down %s.", sig, project.Name))
+			tornDown <- proj.runCommand(project, project.TearDown)
+
+		case <-done:
+			close(tornDown)
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+
+		err, caught := <-tornDown
+
+		if !caught {
+			return
+		}
+
+		if err != nil && err != errNoTearDown {
+			cliError(fmt.Errorf("Tear down failed: %s", err))
+		}
+
+		if err == nil {
+			cliSuccessOut("Torn down " + project.Name)
+		}
+
+		// The usual exit code for a Ctrl-C.
+		cleanUp()
+		exit(130)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build !windows
+
+package main
+
+import (
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestInterruptWithoutTearDown(t *testing.T) {
+
+	proj := newTestProj(t)
+	project := Project{Name: "api", Path: t.TempDir(), Command: "sleep 30", TearDownOnInterrupt: true}
+
+	defer atomic.StoreInt32(&interrupted, 0)
+
+	finish := proj.watchInterrupts(project)
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&interrupted) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if code := expectExit(t, finish); code != 130 {
+		t.Errorf("exited with %d, want 130", code)
+	}
+}
+
+func TestValidateTearDownOnInterrupt(t *testing.T) {
+
+	project := Project{Name: "api", Path: "/srv/api", Command: "make", TearDownOnInterrupt: true}
+
+	if err := validateProject(project); err == nil {
+		t.Error("teardown_on_interrupt without a tear down passed validation")
+	}
+
+	project.TearDown = "make clean"
+
+	if err := validateProject(project); err != nil {
+		t.Errorf("teardown_on_interrupt with a tear down failed validation: %s", err)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Field names structured loggers commonly use, in order of preference.
+var (
+	logLevelKeys   = []string{"level", "lvl", "severity"}
+	logMessageKeys = []string{"msg", "message"}
+	logTimeKeys    = []string{"time", "ts", "timestamp", "@timestamp"}
+)
+
+// jsonLogs - An io.Writer that rewrites JSON log lines as readable ones, like
+// "14:30:00 INFO  listening port=8080". Anything that isn't a JSON object
+// is passed on unchanged.
+type jsonLogs struct {
+	out io.Writer
+
+	mu   sync.Mutex
+	line []byte
+}
+
+// jsonLogWriter - w, with JSON log lines reformatted if --capture-json-logs
+// is set.
+func jsonLogWriter(w io.Writer) io.Writer {
+
+	if !*startJSONLogs {
+		return w
+	}
+
+	return &jsonLogs{out: w}
+}
+
+// Write - Reformat each complete line in p. A partial line is held until
+// the rest arrives, or Flush.
+func (j *jsonLogs) Write(p []byte) (int, error) {
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	n := len(p)
+
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+
+		if i < 0 {
+			j.line = append(j.line, p...)
+			break
+		}
+
+		j.line = append(j.line, p[:i+1]...)
+		p = p[i+1:]
+
+		if _, err := io.WriteString(j.out, formatLogLine(string(j.line))); err != nil {
+			return n, err
+		}
+
+		j.line = j.line[:0]
+	}
+
+	return n, nil
+}
+
+// Flush - Pass on any partial line as it is, then flush the writer below.
+func (j *jsonLogs) Flush() {
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.line) > 0 {
+		j.out.Write(j.line)
+		j.line = j.line[:0]
+	}
+
+	flush(j.out)
+}
+
+// formatLogLine - line as time, level, message, then the remaining fields
+// sorted by name, if it's a JSON object. Otherwise it's returned as it is.
+func formatLogLine(line string) string {
+
+	text := strings.TrimRight(line, "\r\n")
+
+	if !strings.HasPrefix(strings.TrimSpace(text), "{") {
+		return line
+	}
+
+	var fields map[string]interface{}
+
+	if err := json.Unmarshal([]byte(text), &fields); err != nil {
+		return line
+	}
+
+	var parts []string
+
+	if value, ok := takeLogField(fields, logTimeKeys); ok {
+		parts = append(parts, logTime(value))
+	}
+
+	if value, ok := takeLogField(fields, logLevelKeys); ok {
+		parts = append(parts, logLevel(fmt.Sprint(value)))
+	}
+
+	if value, ok := takeLogField(fields, logMessageKeys); ok {
+		parts = append(parts, fmt.Sprint(value))
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := fields[key]
+
+		// Nested values are kept as JSON, so they read back as they were.
+		if _, ok := value.(string); !ok {
+			if data, err := json.Marshal(value); err == nil {
+				value = string(data)
+			}
+		}
+
+		parts = append(parts, color.CyanString(key+"=")+fmt.Sprint(value))
+	}
+
+	return strings.Join(parts, " ") + "\n"
+}
+
+// takeLogField - Remove and return the first of keys that fields has.
+func takeLogField(fields map[string]interface{}, keys []string) (interface{}, bool) {
+
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			delete(fields, key)
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// logTime - A log timestamp as a time of day, from RFC 3339 or Unix
+// seconds. Any other form is shown as it is.
+func logTime(value interface{}) string {
+
+	var at time.Time
+
+	switch value := value.(type) {
+	case float64:
+		sec := int64(value)
+		at = time.Unix(sec, int64((value-float64(sec))*1e9))
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return value
+		}
+		at = parsed
+	default:
+		return fmt.Sprint(value)
+	}
+
+	return color.New(color.Faint).Sprint(at.Local().Format("15:04:05"))
+}
+
+// logLevel - A level, upper cased, padded to line up, and colored by how
+// serious it is.
+func logLevel(level string) string {
+
+	level = fmt.Sprintf("%-5s", strings.ToUpper(level))
+
+	switch strings.TrimSpace(level) {
+	case "ERROR", "ERR", "FATAL", "PANIC", "CRITICAL":
+		return color.RedString(level)
+	case "WARN", "WARNING":
+		return color.YellowString(level)
+	case "INFO":
+		return color.GreenString(level)
+	}
+
+	return color.New(color.Faint).Sprint(level)
+}
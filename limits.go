@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Limits - Caps on what a project's commands can use while they run.
+type Limits struct {
+	// Most memory the commands can use, in bytes or with a K, M or G
+	// suffix, e.g. 2G.
+	Memory string `yaml:"memory,omitempty" json:"memory,omitempty"`
+
+	// Share of one CPU core, e.g. 50% for half a core, or 200% for two.
+	CPU string `yaml:"cpu,omitempty" json:"cpu,omitempty"`
+}
+
+// Whether a warning about unsupported limits has been shown, so it's only
+// shown once however many commands run.
+var limitsWarned bool
+
+// parseMemory - A memory size in bytes, from e.g. 512M or 2G.
+func parseMemory(size string) (int64, error) {
+
+	units := map[string]int64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30}
+	size = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+
+	unit := ""
+	if size != "" && strings.ContainsAny(size[len(size)-1:], "KMG") {
+		unit = size[len(size)-1:]
+		size = size[:len(size)-1]
+	}
+
+	n, err := strconv.ParseInt(size, 10, 64)
+
+	if err != nil || n <= 0 {
+		return 0, errors.New("Invalid memory limit, expected a size like 512M or 2G.")
+	}
+
+	return n * units[unit], nil
+}
+
+// parseCPU - A CPU share as a percentage of one core, from e.g. 50%.
+func parseCPU(share string) (int, error) {
+
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(share), "%"))
+
+	if err != nil || n <= 0 {
+		return 0, errors.New("Invalid CPU limit, expected a percentage of one core like 50% or 200%.")
+	}
+
+	return n, nil
+}
+
+// validate - Check the limits parse.
+func (limits *Limits) validate() error {
+
+	if limits == nil {
+		return nil
+	}
+
+	if limits.Memory != "" {
+		if _, err := parseMemory(limits.Memory); err != nil {
+			return err
+		}
+	}
+
+	if limits.CPU != "" {
+		if _, err := parseCPU(limits.CPU); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withLimits - cmd, wrapped so it runs within the project's limits. On
+// Linux with a systemd user session, that's a transient cgroup scope,
+// which caps memory and CPU. Otherwise only memory can be capped, with
+// prlimit or the shell's ulimit, and anything that can't be applied is
+// warned about and skipped.
+func withLimits(cmd *exec.Cmd, limits *Limits) *exec.Cmd {
+
+	if err := limits.validate(); err != nil {
+		cliError(err)
+	}
+
+	if limits == nil || (limits.Memory == "" && limits.CPU == "") {
+		return cmd
+	}
+
+	var memory int64
+	var cpu int
+
+	if limits.Memory != "" {
+		memory, _ = parseMemory(limits.Memory)
+	}
+
+	if limits.CPU != "" {
+		cpu, _ = parseCPU(limits.CPU)
+	}
+
+	if runtime.GOOS == "windows" {
+		warnLimits("Resource limits aren't supported on Windows, running without them.")
+		return cmd
+	}
+
+	if runtime.GOOS == "linux" && systemdUserSession() {
+		args := []string{"--user", "--scope", "--quiet", "--collect"}
+
+		if memory > 0 {
+			args = append(args, "-p", fmt.Sprintf("MemoryMax=%d", memory))
+		}
+
+		if cpu > 0 {
+			args = append(args, "-p", fmt.Sprintf("CPUQuota=%d%%", cpu))
+		}
+
+		return wrapCommand(cmd, "systemd-run", append(args, "--")...)
+	}
+
+	if cpu > 0 {
+		warnLimits("CPU limits need a systemd user session (cgroups), running without one.")
+	}
+
+	if memory == 0 {
+		return cmd
+	}
+
+	if _, err := exec.LookPath("prlimit"); err == nil {
+		return wrapCommand(cmd, "prlimit", fmt.Sprintf("--as=%d", memory), "--")
+	}
+
+	// ulimit takes kilobytes, and applies to the shell that then becomes
+	// the command.
+	return wrapCommand(cmd, "sh", "-c", fmt.Sprintf(`ulimit -v %d && exec "$@"`, memory/1024), "sh")
+}
+
+// systemdUserSession - Whether systemd-run --user can create scopes, which
+// needs systemd-run and a user manager to talk to.
+func systemdUserSession() bool {
+
+	if _, err := exec.LookPath("systemd-run"); err != nil {
+		return false
+	}
+
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+
+	if runtimeDir == "" {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(runtimeDir, "systemd", "private"))
+
+	return err == nil
+}
+
+// wrapCommand - Run cmd through another program, e.g. prlimit, keeping
+// its directory and environment.
+func wrapCommand(cmd *exec.Cmd, name string, args ...string) *exec.Cmd {
+
+	args = append(args, cmd.Path)
+	args = append(args, cmd.Args[1:]...)
+
+	wrapped := exec.Command(name, args...)
+	wrapped.Dir = cmd.Dir
+	wrapped.Env = cmd.Env
+
+	return wrapped
+}
+
+// warnLimits - Warn that limits weren't applied, once.
+func warnLimits(message string) {
+
+	if !limitsWarned {
+		cliWarn(message)
+		limitsWarned = true
+	}
+}
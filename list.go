@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// listColumn - A column `proj list` can show.
+type listColumn struct {
+	name  string
+	value func(Project) string
+}
+
+// allListColumns - Every column `proj list --columns` accepts.
+var allListColumns = []listColumn{
+	{"ID", func(p Project) string { return p.ID }},
+	{"Name", func(p Project) string { return p.Name }},
+	{"Path", func(p Project) string { return p.Path }},
+	{"Command", func(p Project) string { return p.Command }},
+	{"TearDown", func(p Project) string { return p.TearDown }},
+	{"CreatedAt", func(p Project) string { return p.CreatedAt.Local().Format("2006-01-02 15:04") }},
+	{"UpdatedAt", func(p Project) string { return p.UpdatedAt.Local().Format("2006-01-02 15:04") }},
+	{"Tags", func(p Project) string { return strings.Join(p.Tags, ",") }},
+	{"Category", func(p Project) string { return p.Category }},
+	{"LastRun", lastRun},
+	{"LastUsed", func(p Project) string {
+		if p.LastUsedAt == nil {
+			return "never"
+		}
+		return ago(*p.LastUsedAt)
+	}},
+	{"Notes", func(p Project) string { return p.Notes }},
+	{"State", projectState},
+	{"Git", func(p Project) string {
+		if p.Git == nil {
+			p.Git = gitInfo(p)
+		}
+		return p.Git.String()
+	}},
+}
+
+// Columns shown by each --format.
+var listFormats = map[string][]string{
+	"table": {"ID", "Name", "Command"},
+	"wide":  {"ID", "Name", "Path", "Command", "Tags", "CreatedAt", "LastRun", "LastUsed"},
+}
+
+// List - Print projects as an aligned table, optionally only those with tag,
+// in category, or in state, running or stopped, in sortBy order. With git,
+// each project's branch is shown. With group, there's a table for each
+// category, under its name.
+func (proj *Proj) List(format, columns, tag, category, state, sortBy string, reverse, git, group bool) {
+
+	projects := proj.ListProjects()
+
+	sortProjects(projects, sortBy, reverse)
+
+	if tag != "" {
+		projects = withTag(projects, tag)
+	}
+
+	if category != "" {
+		filed := []Project{}
+		for _, project := range projects {
+			if inCategory(project, strings.Trim(category, "/")) {
+				filed = append(filed, project)
+			}
+		}
+		projects = filed
+	}
+
+	if state != "" {
+		projects = inState(projects, state)
+	}
+
+	if git {
+		for i := range projects {
+			projects[i].Git = gitInfo(projects[i])
+		}
+	}
+
+	if *output == "json" {
+		cliJSON(projects)
+		return
+	}
+
+	if format == "dot" {
+		printProjectsDot(projects)
+		return
+	}
+
+	names := listFormats[format]
+
+	// Show what the projects are tagged with, when filtering by tag.
+	if tag != "" && !contains(names, "Tags") {
+		names = append(names, "Tags")
+	}
+
+	if state != "" && !contains(names, "State") {
+		names = append(names, "State")
+	}
+
+	if git && !contains(names, "Git") {
+		names = append(names, "Git")
+	}
+
+	if columns != "" {
+		names = splitNames([]string{columns})
+	}
+
+	cols := make([]listColumn, 0, len(names))
+	for _, name := range names {
+		cols = append(cols, findListColumn(name))
+	}
+
+	w := newTable()
+
+	if !group {
+		writeListTable(w, cols, projects)
+		w.Flush()
+		return
+	}
+
+	categories, groups := groupByCategory(projects)
+
+	for i, category := range categories {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		heading := category
+		if heading == "" {
+			heading = "Uncategorised"
+		}
+
+		// A line without tabs ends the table above, so each group's
+		// columns line up on their own.
+		fmt.Fprintln(w, cursor+" "+heading)
+		writeListTable(w, cols, groups[category])
+	}
+
+	w.Flush()
+}
+
+// writeListTable - Write a header row, then a row for each project.
+func writeListTable(w io.Writer, cols []listColumn, projects []Project) {
+
+	headers := make([]string, len(cols))
+	for i, col := range cols {
+		headers[i] = strings.ToUpper(col.name)
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, project := range projects {
+		values := make([]string, len(cols))
+		for i, col := range cols {
+			values[i] = col.value(project)
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+}
+
+// projectState - Whether a project's detached process is running.
+func projectState(project Project) string {
+
+	if project.Running() {
+		return "running"
+	}
+
+	return "stopped"
+}
+
+// inState - The projects whose state is state.
+func inState(projects []Project, state string) []Project {
+
+	matched := []Project{}
+
+	for _, project := range projects {
+		if projectState(project) == state {
+			matched = append(matched, project)
+		}
+	}
+
+	return matched
+}
+
+// findListColumn - Look up a column by name, ignoring case.
+func findListColumn(name string) listColumn {
+
+	for _, col := range allListColumns {
+		if strings.EqualFold(col.name, name) {
+			return col
+		}
+	}
+
+	known := make([]string, len(allListColumns))
+	for i, col := range allListColumns {
+		known[i] = col.name
+	}
+
+	cliError(errors.New("Unknown column " + name + ", expected one of: " + strings.Join(known, ", ")))
+	return listColumn{}
+}
+
+// sortProjects - Order projects by name, as they're loaded, by when they
+// were created or last updated (oldest first), or with recent, the most
+// recently used first and those never used last. With reverse, the order
+// is flipped.
+func sortProjects(projects []Project, sortBy string, reverse bool) {
+
+	switch sortBy {
+	case "created":
+		sort.SliceStable(projects, func(i, j int) bool {
+			return projects[i].CreatedAt.Before(projects[j].CreatedAt)
+		})
+	case "updated":
+		sort.SliceStable(projects, func(i, j int) bool {
+			return projects[i].UpdatedAt.Before(projects[j].UpdatedAt)
+		})
+	case "recent":
+		sort.SliceStable(projects, func(i, j int) bool {
+			a, b := projects[i].LastUsedAt, projects[j].LastUsedAt
+			return a != nil && (b == nil || a.After(*b))
+		})
+	}
+
+	if reverse {
+		for i, j := 0, len(projects)-1; i < j; i, j = i+1, j-1 {
+			projects[i], projects[j] = projects[j], projects[i]
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Lock - What a project starts with, pinned in its proj.lock so teammates
+// can tell when their setup differs. Paths differ between machines, so
+// only the programs' contents are compared.
+type Lock struct {
+	Name        string            `yaml:"name"`
+	Commands    []string          `yaml:"commands"`
+	TearDown    string            `yaml:"teardown,omitempty"`
+	PathPrepend []string          `yaml:"path_prepend,omitempty"`
+	Env         map[string]string `yaml:"env,omitempty"`
+	CleanEnv    bool              `yaml:"clean_env,omitempty"`
+	Tools       map[string]Tool   `yaml:"tools,omitempty"`
+}
+
+// Tool - A program a project's commands run, and a hash of its contents.
+type Tool struct {
+	Path   string `yaml:"path"`
+	SHA256 string `yaml:"sha256"`
+}
+
+// lockPath - Where a project's lock lives, next to its proj.yml.
+func lockPath(project Project) string {
+	return filepath.Join(project.Dir(), "proj.lock")
+}
+
+// LockProject - Write the project's current setup to its proj.lock.
+func (proj *Proj) LockProject(name string) {
+
+	project := proj.LoadProject(name)
+
+	if project.Host != "" {
+		cliError(errors.New("proj.lock isn't supported for remote projects."))
+	}
+
+	lock, err := projectLock(project)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	data, err := yaml.Marshal(&lock)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	if err := ioutil.WriteFile(lockPath(project), data, 0644); err != nil {
+		cliError(errors.New("Failed to write proj.lock."))
+	}
+
+	cliSuccessOut("Locked " + project.Name + " to " + lockPath(project))
+}
+
+// checkLock - Fail unless the project still matches its proj.lock.
+func checkLock(project Project) {
+
+	if project.Host != "" {
+		cliError(errors.New("proj.lock isn't supported for remote projects."))
+	}
+
+	data, err := ioutil.ReadFile(lockPath(project))
+
+	if os.IsNotExist(err) {
+		cliError(errors.New("No proj.lock for " + project.Name + ", run proj lock " + project.Name + " to create one."))
+	}
+
+	if err != nil {
+		cliError(err)
+	}
+
+	var locked Lock
+
+	if err := yaml.Unmarshal(data, &locked); err != nil {
+		cliError(errors.New("Failed to parse " + lockPath(project) + ": " + err.Error()))
+	}
+
+	current, err := projectLock(project)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	differences := lockDiff(locked, current)
+
+	for _, difference := range differences {
+		cliWarn(difference)
+	}
+
+	if len(differences) > 0 {
+		cliError(fmt.Errorf("%s doesn't match its proj.lock, %d difference(s).", project.Name, len(differences)))
+	}
+}
+
+// projectLock - The project's setup as it is now.
+func projectLock(project Project) (Lock, error) {
+
+	lock := Lock{
+		Name:        project.Name,
+		Commands:    project.StartCommands(),
+		TearDown:    project.TearDown,
+		PathPrepend: project.PathPrepend,
+		Env:         project.Env,
+		CleanEnv:    project.CleanEnv,
+		Tools:       map[string]Tool{},
+	}
+
+	commands := project.StartCommands()
+	if project.TearDown != "" {
+		commands = append(commands, project.TearDown)
+	}
+
+	path := getEnv(project.Environ(), "PATH")
+
+	for _, command := range commands {
+		bin := commandBinary(command)
+
+		if bin == "" || shellBuiltins[bin] {
+			continue
+		}
+
+		// Missing programs are left out, and show up as a difference.
+		file, err := lookPath(bin, project.Dir(), path)
+
+		if err != nil {
+			continue
+		}
+
+		sum, err := hashFile(file)
+
+		if err != nil {
+			return lock, err
+		}
+
+		lock.Tools[bin] = Tool{Path: file, SHA256: sum}
+	}
+
+	return lock, nil
+}
+
+// hashFile - The hex SHA-256 of a file's contents.
+func hashFile(file string) (string, error) {
+
+	f, err := os.Open(file)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	hash := sha256.New()
+
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// lockDiff - How current differs from what was locked.
+func lockDiff(locked, current Lock) []string {
+
+	var differences []string
+
+	if !reflect.DeepEqual(locked.Commands, current.Commands) {
+		differences = append(differences, fmt.Sprintf("Commands changed from %q to %q.", locked.Commands, current.Commands))
+	}
+
+	if locked.TearDown != current.TearDown {
+		differences = append(differences, fmt.Sprintf("Tear down changed from %q to %q.", locked.TearDown, current.TearDown))
+	}
+
+	if strings.Join(locked.PathPrepend, ":") != strings.Join(current.PathPrepend, ":") {
+		differences = append(differences, fmt.Sprintf("Path prepend changed from %q to %q.", locked.PathPrepend, current.PathPrepend))
+	}
+
+	if locked.CleanEnv != current.CleanEnv {
+		differences = append(differences, fmt.Sprintf("Clean env changed from %t to %t.", locked.CleanEnv, current.CleanEnv))
+	}
+
+	for _, key := range unionKeys(locked.Env, current.Env) {
+		was, wasSet := locked.Env[key]
+		now, isSet := current.Env[key]
+
+		switch {
+		case !isSet:
+			differences = append(differences, "Env "+key+" was removed.")
+		case !wasSet:
+			differences = append(differences, "Env "+key+" was added.")
+		case was != now:
+			differences = append(differences, fmt.Sprintf("Env %s changed from %q to %q.", key, was, now))
+		}
+	}
+
+	names := make([]string, 0, len(locked.Tools))
+	for name := range locked.Tools {
+		names = append(names, name)
+	}
+	for name := range current.Tools {
+		if _, ok := locked.Tools[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		was, wasFound := locked.Tools[name]
+		now, isFound := current.Tools[name]
+
+		switch {
+		case !isFound:
+			differences = append(differences, name+" is no longer run, or wasn't found.")
+		case !wasFound:
+			differences = append(differences, name+" is run, but isn't in the lock.")
+		case was.SHA256 != now.SHA256:
+			differences = append(differences, fmt.Sprintf("%s at %s is a different version from the locked one.", name, now.Path))
+		}
+	}
+
+	return differences
+}
+
+// unionKeys - Every key in either map, sorted.
+func unionKeys(a, b map[string]string) []string {
+
+	seen := map[string]bool{}
+	var keys []string
+
+	for _, m := range []map[string]string{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// How often a followed log is checked for new output.
+const followInterval = 250 * time.Millisecond
+
+// Timestamp at the start of each line of a detached project's log.
+const logTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// LogOptions - Which part of a log to print, and how.
+type LogOptions struct {
+	Follow     bool
+	Tail       int           // Only the last Tail lines, if set.
+	Since      time.Duration // Only lines logged in the last Since, if set.
+	Timestamps bool
+}
+
+// Logs - Print a detached project's log, and with follow, keep printing
+// new output until interrupted.
+func (proj *Proj) Logs(name string, opts LogOptions) {
+
+	project := proj.LoadProject(name)
+	path := logPath(project)
+
+	if _, err := os.Stat(path); err != nil {
+		cliError(err)
+	}
+
+	out := highlightWriter(streamWriter(os.Stdout), project)
+	defer flush(out)
+
+	if err := printLog(path, opts, out); err != nil {
+		cliError(err)
+	}
+}
+
+// LogWriter - Copy stdin to the log at path, a line at a time, with each
+// line timestamped. Run by detached projects as proj log-writer.
+func LogWriter(path string) {
+
+	log, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	defer log.Close()
+
+	// The writer shares the project's process group, so it sees every signal
+	// sent to it. It outlives the project by reading until the pipe closes.
+	signal.Ignore()
+
+	// Normalized on the way in, so the log reads cleanly however it's read.
+	input, normalized := io.Pipe()
+	go func() {
+		out := outputWriter(normalized)
+		io.Copy(out, os.Stdin)
+		flush(out)
+		normalized.Close()
+	}()
+
+	reader := bufio.NewReader(input)
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if line != "" {
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			fmt.Fprintf(log, "%s %s", time.Now().Format(logTimeFormat), line)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// splitLogLine - A log line's timestamp and text. Lines from before logs
+// were timestamped get the zero time.
+func splitLogLine(line string) (time.Time, string) {
+
+	if i := strings.IndexByte(line, ' '); i > 0 {
+		if t, err := time.Parse(logTimeFormat, line[:i]); err == nil {
+			return t, line[i+1:]
+		}
+	}
+
+	return time.Time{}, line
+}
+
+// printLog - Copy the log at path to out. With follow, like tail -f, keep
+// copying as it grows, reopening it if it's rotated or truncated.
+func printLog(path string, opts LogOptions, out io.Writer) error {
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() { file.Close() }()
+
+	if opts.Tail > 0 {
+		offset, err := tailOffset(file, opts.Tail)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	var since time.Time
+	if opts.Since > 0 {
+		since = time.Now().Add(-opts.Since)
+	}
+
+	// Untimestamped lines go with whatever came before them.
+	show := since.IsZero()
+	partial := ""
+
+	reader := bufio.NewReader(file)
+
+	// copyLines - Print every whole line read so far.
+	copyLines := func() error {
+		for {
+			chunk, err := reader.ReadString('\n')
+			partial += chunk
+
+			if err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+
+			t, text := splitLogLine(partial)
+
+			if !t.IsZero() {
+				show = !t.Before(since)
+			}
+
+			if show && opts.Timestamps {
+				io.WriteString(out, partial)
+			} else if show {
+				io.WriteString(out, text)
+			}
+
+			partial = ""
+		}
+	}
+
+	if err := copyLines(); err != nil {
+		return err
+	}
+
+	if !opts.Follow {
+		// The last line may not be finished yet.
+		if show && partial != "" {
+			io.WriteString(out, partial+"\n")
+		}
+		return nil
+	}
+
+	opened, err := file.Stat()
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		time.Sleep(followInterval)
+
+		if err := copyLines(); err != nil {
+			return err
+		}
+
+		flush(out)
+
+		current, err := os.Stat(path)
+
+		// Mid-rotation, the file may briefly not exist.
+		if err != nil {
+			continue
+		}
+
+		// Rotated, a new file has taken its place.
+		if !os.SameFile(opened, current) {
+			if next, err := os.Open(path); err == nil {
+				copyLines()
+				file.Close()
+				file, opened = next, current
+				reader.Reset(file)
+			}
+			continue
+		}
+
+		// Truncated in place, start again from the top.
+		if pos, err := file.Seek(0, io.SeekCurrent); err == nil && current.Size() < pos {
+			file.Seek(0, io.SeekStart)
+			reader.Reset(file)
+		}
+	}
+}
+
+// tailOffset - Where the last n lines of file start. Reads back from the
+// end a block at a time, so only the tail of a big log is read.
+func tailOffset(file *os.File, n int) (int64, error) {
+
+	info, err := file.Stat()
+
+	if err != nil {
+		return 0, err
+	}
+
+	size := info.Size()
+	buf := make([]byte, 64*1024)
+	pos := size
+	found := 0
+
+	for pos > 0 {
+		chunk := int64(len(buf))
+		if pos < chunk {
+			chunk = pos
+		}
+		pos -= chunk
+
+		if _, err := file.ReadAt(buf[:chunk], pos); err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		for i := chunk - 1; i >= 0; i-- {
+			// The newline ending the file doesn't start another line.
+			if buf[i] != '\n' || pos+i == size-1 {
+				continue
+			}
+
+			if found++; found == n {
+				return pos + i + 1, nil
+			}
+		}
+	}
+
+	return 0, nil
+}
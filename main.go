@@ -3,14 +3,25 @@ package main
 import (
 
 	// Core
-	"bytes"
+	"bufio"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	// Third party
 	"github.com/fatih/color"
@@ -25,22 +36,329 @@ var (
 	// Create newi cli app instance.
 	app = kingpin.New("app", "Codebase project management for pro's.")
 
+	// $ proj --output=json which my-project
+	output  = app.Flag("output", "Output format for inspection commands.").Default("text").Enum("text", "json")
+	noColor = app.Flag("no-color", "Disable colored output.").Bool()
+	noPager = app.Flag("no-pager", "Don't page the output of list, show and logs.").Bool()
+
+	// $ proj --cpu-profile=start.prof start --all
+	cpuProfile = app.Flag("cpu-profile", "Write a CPU profile of proj itself to this file.").Hidden().String()
+	traceFile  = app.Flag("trace", "Write an execution trace of proj itself to this file.").Hidden().String()
+
+	maxLinesPerSec = app.Flag("max-lines-per-sec", "Drop streamed output lines beyond this many a second, noting how many were dropped.").PlaceHolder("N").Int()
+
 	// $ proj init --name=MyProject --command="docker-compose build"
-	initProject         = app.Command("init", "Create a new project.")
-	initProjectName     = initProject.Flag("name", "Project name").Required().String()
-	initProjectPath     = initProject.Flag("path", "Project path.").Required().String()
-	initProjectCommand  = initProject.Flag("command", "Boot command.").Required().String()
-	initProjectTearDown = initProject.Flag("teardown", "Tear down command.").String()
+	initProject            = app.Command("init", "Create a new project.")
+	initProjectName        = initProject.Flag("name", "Project name, required unless interactive.").String()
+	initProjectID          = initProject.Flag("id", "Project ID, generated if not given.").String()
+	initProjectPath        = initProject.Flag("path", "Project path, required unless interactive.").String()
+	initProjectCommand     = initProject.Flag("command", "Boot command, repeat to run several in order. Required unless interactive.").Strings()
+	initProjectTearDown    = initProject.Flag("teardown", "Tear down command.").String()
+	initProjectPathPrepend = initProject.Flag("path-prepend", "Directory to prepend to PATH, relative to the project path.").Strings()
+	initProjectStopCheck   = initProject.Flag("stopped-check", "Command that succeeds if the project is still running after tear down.").String()
+	initProjectStopPort    = initProject.Flag("stopped-port", "Port that should be free after tear down.").Int()
+	initProjectEnv         = initProject.Flag("env", "Environment variable for commands, as KEY=VALUE.").StringMap()
+	initProjectEnvFile     = initProject.Flag("env-file", "A .env file, relative to the path, whose values override --env.").String()
+	initProjectPidFile     = initProject.Flag("pid-file", "File, relative to the path, that detached starts write their pid to.").String()
+	initProjectColorRules  = initProject.Flag("color-rule", "Color output lines matching a regex, as REGEX=COLOR, e.g. ERROR=red (repeatable, first match wins).").PlaceHolder("REGEX=COLOR").Strings()
+	initProjectCategory    = initProject.Flag("category", "Category to file the project under, e.g. client-acme or client-acme/api.").String()
+	initProjectNetwork     = initProject.Flag("network-check", "host:port to check is reachable before every start, e.g. an image registry.").String()
+	initProjectMemory      = initProject.Flag("memory-limit", "Most memory the commands can use, e.g. 2G.").String()
+	initProjectCPU         = initProject.Flag("cpu-limit", "Share of a CPU core the commands can use, e.g. 50%.").String()
+	initProjectCleanEnv    = initProject.Flag("clean-env", "Don't inherit proj's environment, only PATH and --env.").Bool()
+	initProjectWaitFor     = initProject.Flag("wait-for", "host:port to wait for before starting, repeatable.").Strings()
+	initProjectContinue    = initProject.Flag("continue-on-error", "Keep running later boot commands when one fails.").Bool()
+	initProjectNotes       = initProject.Flag("notes", "Free-form notes about the project.").String()
+	initProjectVerify      = initProject.Flag("verify-command", "Side-effect free command that checks the project can start.").String()
+	initProjectHost        = initProject.Flag("host", "Run commands on this [user@]host over ssh, path is then remote.").String()
+	initProjectScripts     = initProject.Flag("script", "Named task for proj do, as NAME=COMMAND.").StringMap()
+	initProjectTags        = initProject.Flag("tag", "Tag to group the project by, repeatable.").Strings()
+	initProjectDetach      = initProject.Flag("detach", "Start in the background, logging to a file.").Bool()
+	initProjectTDInterrupt = initProject.Flag("teardown-on-interrupt", "Run the tear down when a start is interrupted.").Bool()
+	initProjectDependsOn   = initProject.Flag("depends-on", "Project this one needs, repeatable.").Strings()
+	initProjectMode        = initProject.Flag("mode", "Run commands through the shell (default), or exec them directly.").Enum("shell", "exec")
+	initProjectStdin       = initProject.Flag("command-stdin", "Read the boot command from stdin, e.g. a multi-line script.").Bool()
+	initProjectDetect      = initProject.Flag("detect", "Fill in the commands from the type of project, e.g. npm start for package.json.").Bool()
+	initProjectUpdate      = initProject.Flag("update-if-exists", "Update the project with the given fields if it already exists.").Bool()
+	initProjectInteractive = initProject.Flag("interactive", "Prompt for the name, path, commands and tags.").Short('i').Bool()
 
 	// $ proj commit
 	commit = app.Command("commit", "Commit a config file change.")
 
 	// $ proj start my-project
-	start     = app.Command("start", "Start your project.")
-	startName = start.Arg("name", "Project name.").Required().String()
-
-	stop     = app.Command("stop", "Stop your project.")
-	stopName = stop.Arg("name", "Project name.").Required().String()
+	// $ proj start --all --skip=ml-service
+	start            = app.Command("start", "Start your project.")
+	startName        = start.Arg("name", "Project name.").String()
+	startID          = start.Flag("id", "Project ID, instead of a name.").String()
+	startAll         = start.Flag("all", "Start every project.").Bool()
+	startOnly        = start.Flag("only", "With --all, only start these projects (comma separated).").Strings()
+	startSkip        = start.Flag("skip", "With --all, skip these projects (comma separated).").Strings()
+	startWait        = start.Flag("wait-for", "host:port to wait for before starting, repeatable.").Strings()
+	startWaitTimeout = start.Flag("wait-timeout", "How long to wait for --wait-for endpoints.").Default(defaultWaitTimeout.String()).Duration()
+	startRecord      = start.Flag("record", "Record the run, with timestamped output, to this file.").String()
+	startCast        = start.Flag("record-asciinema", "Record the output, with its timing, to this file as an asciinema cast.").PlaceHolder("FILE").String()
+	startTee         = start.Flag("tee", "Show the output as it runs, and append it to this file too.").PlaceHolder("FILE").String()
+	startLabels      = start.Flag("label", "Label for this run, as KEY=VALUE, kept in its history, events and report.").PlaceHolder("KEY=VALUE").StringMap()
+	startReport      = start.Flag("report", "Write a JSON summary of the run to this file.").String()
+	startPrintEnv    = start.Flag("print-env", "Print the resolved environment, with secrets masked.").Bool()
+	startDryRun      = start.Flag("dry-run", "Show what would run, without running it.").Bool()
+	startDetach      = start.Flag("detach", "Start in the background, logging to a file.").Short('d').Bool()
+	startUntil       = start.Flag("until", "Show output until a line matches this regex, then leave it running in the background.").Regexp()
+	startLocked      = start.Flag("locked", "Refuse to start if the project differs from its proj.lock.").Bool()
+	startExec        = start.Flag("exec", "Run the commands directly, without a shell, this time.").Bool()
+	startEnvProfile  = start.Flag("env-profile", "Env profile from proj.yml to layer over the env and env file.").String()
+	startEnv         = start.Flag("env", "Environment variable for this start, as KEY=VALUE, overriding all others.").StringMap()
+	startEnvSources  = start.Flag("show-env-sources", "Print the resolved environment, noting where each value came from.").Bool()
+	startPidFile     = start.Flag("pid-file", "Write the detached process's pid to this file, removed on stop.").String()
+	startDir         = start.Flag("dir", "Run in this directory instead of the project's path, e.g. a worktree, without saving it.").String()
+	startJSONLogs    = start.Flag("capture-json-logs", "Show JSON log lines as readable ones, with the level, message and time first.").Bool()
+	startNetwork     = start.Flag("network-check", "Check the network is up before starting, by reaching the project's network_check host, or "+defaultNetworkCheck+".").Bool()
+	startCategory    = start.Flag("category", "Start every project in this category, and its subcategories.").String()
+	startOnExit      = start.Flag("on-exit", "What to do when the project exits: stop, or restart it if it failed.").Default("stop").Enum("stop", "restart")
+	startMaxRestarts = start.Flag("max-restarts", "With --on-exit=restart, how many times to restart before giving up.").Default("5").Int()
+	startBackoff     = start.Flag("restart-backoff", "With --on-exit=restart, how long to wait before the first restart, doubling each time.").Default("1s").Duration()
+	startAlways      = start.Flag("always", "With --on-exit=restart, restart after a clean exit too.").Bool()
+	startQuiet       = start.Flag("quiet-on-success", "Hold back the output, and only print it if the start fails.").Bool()
+	startReplace     = start.Flag("replace", "Stop the project first if it's already running.").Bool()
+	startMaxRuntime  = start.Flag("max-runtime", "Once detached, stop the project after this long, e.g. 8h, if it's still running.").Duration()
+	startHealth      = start.Flag("health-retries", "Once detached, check its stopped check or port up to N times, a second apart, failing at once if it crashes.").PlaceHolder("N").Int()
+
+	// $ proj do test --all --jobs=4
+	do      = app.Command("do", "Run a named script across projects.")
+	doName  = do.Arg("script", "Script name.").Required().String()
+	doNames = do.Arg("projects", "Project names.").Strings()
+	doAll   = do.Flag("all", "Run in every project that has the script.").Bool()
+	doOnly  = do.Flag("only", "With --all, only these projects (comma separated).").Strings()
+	doSkip  = do.Flag("skip", "With --all, skip these projects (comma separated).").Strings()
+	doJobs  = do.Flag("jobs", "How many projects to run at once.").Short('j').Default("1").Int()
+
+	// $ proj exec-all "git pull" --tag=backend
+	execAll        = app.Command("exec-all", "Run a shell command in every project's directory.")
+	execAllCommand = execAll.Arg("command", "Shell command.").Required().String()
+	execAllTag     = execAll.Flag("tag", "Only run in projects with this tag.").String()
+	execAllOnly    = execAll.Flag("only", "Only run in these projects (comma separated).").Strings()
+	execAllSkip    = execAll.Flag("skip", "Skip these projects (comma separated).").Strings()
+	execAllJobs    = execAll.Flag("jobs", "How many projects to run in at once.").Short('j').Default("1").Int()
+
+	// $ proj last
+	last = app.Command("last", "Start the last project started again.")
+
+	// $ proj ensure my-db --detach
+	ensure       = app.Command("ensure", "Start a project, unless it's already running.")
+	ensureName   = ensure.Arg("name", "Project name.").Required().String()
+	ensureDetach = ensure.Flag("detach", "Start in the background, logging to a file.").Short('d').Bool()
+	ensureUntil  = ensure.Flag("until", "Show output until a line matches this regex, then leave it running in the background.").Regexp()
+
+	stop        = app.Command("stop", "Stop your project.")
+	stopName    = stop.Arg("name", "Project name.").String()
+	stopID      = stop.Flag("id", "Project ID, instead of a name.").String()
+	stopReport  = stop.Flag("report", "Write a JSON summary of the tear down to this file.").String()
+	stopAll     = stop.Flag("all", "Stop every project.").Bool()
+	stopOnly    = stop.Flag("only", "With --all, only stop these projects (comma separated).").Strings()
+	stopSkip    = stop.Flag("skip", "With --all, skip these projects (comma separated).").Strings()
+	stopSignal  = stop.Flag("signal", "Send this signal (e.g. SIGHUP) to the detached process group, instead of tearing down.").String()
+	stopNoWarn  = stop.Flag("no-teardown-warn", "Don't warn about projects skipped for having no tear down.").Bool()
+	stopForce   = stop.Flag("force", "Stop it even if running projects depend on it.").Bool()
+	stopCascade = stop.Flag("cascade", "Stop the running projects that depend on it first.").Bool()
+
+	// $ proj edit my-project
+	// $ proj edit my-project --notes="Staging creds in 1Password"
+	edit      = app.Command("edit", "Edit a project in $EDITOR, or set fields with flags.")
+	editName  = edit.Arg("name", "Project name.").Required().String()
+	editNotes = edit.Flag("notes", "Replace the project's notes.").String()
+	editStdin = edit.Flag("command-stdin", "Replace the boot command with a script read from stdin.").Bool()
+
+	// $ proj stats --since=168h
+	stats      = app.Command("stats", "Show how often each project is started, and how often it fails.")
+	statsSince = stats.Flag("since", "Only count runs started in this long, e.g. 168h.").Duration()
+	statsSort  = stats.Flag("sort", "Sort by runs, failures, last or name.").Default("runs").Enum("runs", "failures", "last", "name")
+
+	// $ proj prune-logs --max-age=168h
+	pruneLogs        = app.Command("prune-logs", "Remove old logs, by the retention policy in config or the flags given.")
+	pruneLogsMaxAge  = pruneLogs.Flag("max-age", "Remove logs not written to for this long, e.g. 168h.").Duration()
+	pruneLogsMaxSize = pruneLogs.Flag("max-size", "Remove the oldest logs until they take up no more than this, e.g. 500M.").String()
+	pruneLogsDryRun  = pruneLogs.Flag("dry-run", "Show what would be removed, without removing it.").Bool()
+
+	// $ proj diff-env my-project
+	diffEnvCommand = app.Command("diff-env", "Compare the env proj would give a project with its running process's.")
+	diffEnvName    = diffEnvCommand.Arg("name", "Project name.").Required().String()
+
+	// $ proj validate ~/code/*/proj.yml --fix
+	validate      = app.Command("validate", "Check project files for problems, and with --fix correct the ones that can be.")
+	validatePaths = validate.Arg("paths", "Project files, or directories holding them, default the current directory.").Strings()
+	validateFix   = validate.Flag("fix", "Correct what can be: relative paths, whitespace around commands, missing IDs and unknown fields.").Bool()
+	validateYes   = validate.Flag("yes", "Write the fixes without asking.").Short('y').Bool()
+
+	// $ proj info
+	infoCommand = app.Command("info", "Show where proj keeps its config, database and logs, and the schema version.")
+
+	autoStop      = app.Command("auto-stop", "").Hidden()
+	autoStopName  = autoStop.Arg("name", "").Required().String()
+	autoStopPid   = autoStop.Flag("pid", "").Required().Int()
+	autoStopAfter = autoStop.Flag("after", "").Required().Duration()
+
+	// $ proj check-deps
+	checkDepsCommand = app.Command("check-deps", "List the programs every project needs, and which aren't installed.")
+
+	// $ proj events --follow
+	events       = app.Command("events", "Print start, stop and failure events as JSON lines, for other tools.")
+	eventsFollow = events.Flag("follow", "Keep printing new events as they happen.").Short('f').Bool()
+	eventsSince  = events.Flag("since", "Only events from this long ago on, e.g. 1h.").Duration()
+
+	// $ proj touch my-project
+	touch     = app.Command("touch", "Mark a project as just used, without starting it.")
+	touchName = touch.Arg("name", "Project name.").Required().String()
+
+	// $ proj cat my-project --from-db
+	cat       = app.Command("cat", "Print a project's proj.yml.")
+	catName   = cat.Arg("name", "Project name.").Required().String()
+	catFromDB = cat.Flag("from-db", "Print the proj.yml the database would generate instead.").Bool()
+
+	// $ proj lock my-project
+	lock     = app.Command("lock", "Pin a project's commands, env and programs in a proj.lock.")
+	lockName = lock.Arg("name", "Project name.").Required().String()
+
+	// $ proj verify my-project
+	verify     = app.Command("verify", "Check a project can start, without starting it.")
+	verifyName = verify.Arg("name", "Project name.").Required().String()
+
+	// $ proj which my-project
+	which         = app.Command("which", "Print the command a project would run, without running it.")
+	whichName     = which.Arg("name", "Project name.").Required().String()
+	whichTearDown = which.Flag("teardown", "Show the tear down command instead.").Bool()
+
+	// $ proj list --format=wide
+	list         = app.Command("list", "List projects.")
+	listFormat   = list.Flag("format", "Table layout, table or wide, or dot for a Graphviz graph of the projects and their dependencies.").Default("table").Enum("table", "wide", "dot")
+	listColumns  = list.Flag("columns", "Comma separated columns to show, e.g. Name,Path. Overrides --format.").String()
+	listTag      = list.Flag("tag", "Only list projects with this tag.").String()
+	listCategory = list.Flag("category", "Only list projects in this category, or its subcategories.").String()
+	listGroup    = list.Flag("by-category", "Group the projects by category, under a heading for each.").Bool()
+	listRunning  = list.Flag("running", "Only list projects with a running detached process.").Bool()
+	listStopped  = list.Flag("stopped", "Only list projects without a running detached process.").Bool()
+	listGit      = list.Flag("git", "Show each project's git branch, and whether it has uncommitted changes.").Bool()
+	listSort     = list.Flag("sort", "Order by name, created, updated, or recent (most recently used).").Default("name").Enum("name", "created", "updated", "recent")
+	listReverse  = list.Flag("reverse", "Reverse the order.").Bool()
+
+	// $ proj tag add my-project backend
+	tagCommand    = app.Command("tag", "Manage project tags.")
+	tagAdd        = tagCommand.Command("add", "Tag a project.")
+	tagAddName    = tagAdd.Arg("name", "Project name.").Required().String()
+	tagAddTag     = tagAdd.Arg("tag", "Tag.").Required().String()
+	tagRemove     = tagCommand.Command("rm", "Remove a tag from a project.")
+	tagRemoveName = tagRemove.Arg("name", "Project name.").Required().String()
+	tagRemoveTag  = tagRemove.Arg("tag", "Tag.").Required().String()
+	tagRename     = tagCommand.Command("rename", "Rename a tag across every project.")
+	tagRenameOld  = tagRename.Arg("old", "Current tag.").Required().String()
+	tagRenameNew  = tagRename.Arg("new", "New tag.").Required().String()
+
+	// $ proj alias add auth authentication-microservice
+	aliasCommand     = app.Command("alias", "Manage short names for projects.")
+	aliasAdd         = aliasCommand.Command("add", "Add an alias for a project.")
+	aliasAddAlias    = aliasAdd.Arg("alias", "Alias.").Required().String()
+	aliasAddName     = aliasAdd.Arg("name", "Project name.").Required().String()
+	aliasRemove      = aliasCommand.Command("rm", "Remove an alias.")
+	aliasRemoveAlias = aliasRemove.Arg("alias", "Alias.").Required().String()
+	aliasList        = aliasCommand.Command("list", "List aliases.")
+
+	// $ proj secret set github/token
+	secret           = app.Command("secret", "Manage secrets in the OS keyring, for ${keyring:service/key} env values.")
+	secretSet        = secret.Command("set", "Store a secret.")
+	secretSetName    = secretSet.Arg("name", "Secret name, as service/key.").Required().String()
+	secretSetValue   = secretSet.Arg("value", "Secret value, read from stdin if not given.").String()
+	secretGet        = secret.Command("get", "Print a secret.")
+	secretGetName    = secretGet.Arg("name", "Secret name, as service/key.").Required().String()
+	secretRemove     = secret.Command("rm", "Remove a secret.")
+	secretRemoveName = secretRemove.Arg("name", "Secret name, as service/key.").Required().String()
+
+	// $ proj logs my-project --follow
+	logs       = app.Command("logs", "Print the log of a detached project.")
+	logsName   = logs.Arg("name", "Project name.").Required().String()
+	logsFollow = logs.Flag("follow", "Keep printing new output as it's written.").Short('f').Bool()
+	logsTail   = logs.Flag("tail", "Only print the last N lines.").PlaceHolder("N").Int()
+	logsSince  = logs.Flag("since", "Only print lines logged in this long, e.g. 10m.").Duration()
+	logsTimes  = logs.Flag("timestamps", "Print each line's timestamp.").Short('t').Bool()
+
+	// Timestamps a detached project's output into its log.
+	logWriter     = app.Command("log-writer", "").Hidden()
+	logWriterPath = logWriter.Arg("path", "").Required().String()
+
+	// $ proj deps my-project
+	deps     = app.Command("deps", "Print a project's dependency tree.")
+	depsName = deps.Arg("name", "Project name.").String()
+	depsAll  = deps.Flag("all", "Print the whole dependency graph.").Bool()
+	depsDot  = deps.Flag("dot", "Print the graph in Graphviz DOT format.").Bool()
+
+	// $ proj show my-project
+	show     = app.Command("show", "Show a project's settings and last run.")
+	showName = show.Arg("name", "Project name.").String()
+	showID   = show.Flag("id", "Project ID, instead of a name.").String()
+	showGit  = show.Flag("git", "Show the git branch, and whether it has uncommitted changes.").Bool()
+
+	// $ proj status
+	status = app.Command("status", "Show the last run of every project.")
+
+	// $ proj config set shell bash
+	configCommand  = app.Command("config", "Manage global settings.")
+	configGet      = configCommand.Command("get", "Print a setting.")
+	configGetKey   = configGet.Arg("key", "Setting name.").Required().String()
+	configSet      = configCommand.Command("set", "Change a setting, an empty value resets it.")
+	configSetKey   = configSet.Arg("key", "Setting name.").Required().String()
+	configSetValue = configSet.Arg("value", "Setting value.").Required().String()
+	configList     = configCommand.Command("list", "Print every setting.")
+
+	// $ proj snapshot ~/backups/proj.db
+	snapshot     = app.Command("snapshot", "Back up the whole database to a file.")
+	snapshotFile = snapshot.Arg("file", "Snapshot file.").Required().String()
+
+	// $ proj restore ~/backups/proj.db
+	restore     = app.Command("restore", "Replace the database with a snapshot.")
+	restoreFile = restore.Arg("file", "Snapshot file.").Required().ExistingFile()
+	restoreYes  = restore.Flag("yes", "Don't ask for confirmation.").Short('y').Bool()
+
+	// $ proj repair
+	repair = app.Command("repair", "Check the database, and fix its schema and leftover rows.")
+
+	// $ proj move-db ~/.proj/projects.db
+	moveDB          = app.Command("move-db", "Move the database, and point the config at it.")
+	moveDBPath      = moveDB.Arg("path", "New database path.").Required().String()
+	moveDBForce     = moveDB.Flag("force", "Overwrite an existing file at the new path.").Bool()
+	moveDBRemoveOld = moveDB.Flag("remove-old", "Delete the old database once moved.").Bool()
+
+	// $ proj migrate-config
+	migrateConfig      = app.Command("migrate-config", "Move a database at the old default, "+defaultDBPath+", into ~/.proj, and write a config file for it.")
+	migrateConfigClean = migrateConfig.Flag("clean", "Delete the old database once moved.").Bool()
+
+	// $ proj archive my-project
+	archive     = app.Command("archive", "Archive a project and remove it from the database.")
+	archiveName = archive.Arg("name", "Project name.").Required().String()
+	archiveDir  = archive.Flag("dir", "Archive directory, defaults to ~/.proj/archive.").String()
+
+	// $ proj import projects.json
+	// $ proj import --from-makefile Makefile
+	importCommand  = app.Command("import", "Add projects from proj.yml, archive or list --output=json files, all or nothing.")
+	importFiles    = importCommand.Arg("files", "Files to import.").Required().ExistingFiles()
+	importPartial  = importCommand.Flag("partial", "Keep the projects that import cleanly, even if others fail.").Bool()
+	importMakefile = importCommand.Flag("from-makefile", "The files are Makefiles: make a project of each, with a script per target.").Bool()
+
+	// $ proj serve --addr=:8080
+	serve      = app.Command("serve", "Serve an HTTP API for listing, starting and stopping projects.")
+	serveAddr  = serve.Flag("addr", "Address to listen on.").Default("127.0.0.1:8080").String()
+	serveToken = serve.Flag("token", "Bearer token needed to start and stop projects, generated if not given.").Envar("PROJ_API_TOKEN").String()
+
+	// $ proj completion bash --dynamic >> ~/.bashrc
+	completion             = app.Command("completion", "Print a shell completion script.")
+	completionShell        = completion.Arg("shell", "Shell to complete in.").Default("bash").Enum("bash", "zsh")
+	completionDynamic      = completion.Flag("dynamic", "Also complete project names, tags, env profiles and scripts, looked up as you type.").Bool()
+	completionListProjects = completion.Flag("list-projects", "").Hidden().Bool()
+	completionListTags     = completion.Flag("list-tags", "").Hidden().Bool()
+	completionListProfiles = completion.Flag("list-profiles", "").Hidden().Bool()
+	completionListScripts  = completion.Flag("list-scripts", "").Hidden().Bool()
+	completionProject      = completion.Flag("project", "").Hidden().String()
+
+	// $ proj unarchive ~/.proj/archive/my-project-20170102T150405.yml
+	unarchive     = app.Command("unarchive", "Restore an archived project.")
+	unarchiveFile = unarchive.Arg("file", "Archive file.").Required().ExistingFile()
 )
 
 // SQL statements
@@ -57,44 +375,275 @@ var (
     `
 
 	add = `
-        INSERT OR REPLACE INTO projects(
+        INSERT INTO projects(
             Id, 
             Name,
             Path,
             Command,
             TearDown,
-            CreatedAt
-        ) values(?, ?, ?, ?, ?, CURRENT_TIMESTAMP);
+            PathPrepend,
+            StoppedCheck,
+            StoppedPort,
+            Env,
+            CleanEnv,
+            WaitFor,
+            Commands,
+            ContinueOnError,
+            Notes,
+            VerifyCommand,
+            Host,
+            Scripts,
+            Detach,
+            TearDownOnInterrupt,
+            DependsOn,
+            Mode,
+            EnvFile,
+            EnvProfiles,
+            Limits,
+            PidFile,
+            NetworkCheck,
+            Category,
+            ColorRules,
+            CreatedAt,
+            UpdatedAt
+        ) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
     `
 
 	update = `
         UPDATE projects
-        SET Name = ?, Command = ?, Path = ?, TearDown = ?
+        SET Name = ?, Path = ?, Command = ?, TearDown = ?, PathPrepend = ?,
+            StoppedCheck = ?, StoppedPort = ?, Env = ?, CleanEnv = ?,
+            WaitFor = ?, Commands = ?, ContinueOnError = ?, Notes = ?,
+            VerifyCommand = ?, Host = ?, Scripts = ?, Detach = ?,
+            TearDownOnInterrupt = ?, DependsOn = ?, Mode = ?, EnvFile = ?,
+            EnvProfiles = ?, Limits = ?, PidFile = ?, NetworkCheck = ?,
+            Category = ?, ColorRules = ?, UpdatedAt = CURRENT_TIMESTAMP
         WHERE Id = ?
     `
 
 	find = `
-        SELECT Id, Name, Command, Path, TearDown FROM projects
+        SELECT Id, Name, Command, Path, TearDown, PathPrepend,
+            StoppedCheck, StoppedPort, Env, CleanEnv, WaitFor,
+            LastExitCode, LastRunAt, CreatedAt, Commands, ContinueOnError,
+            Notes, VerifyCommand, Host, Scripts, Detach, Pid,
+            TearDownOnInterrupt, DependsOn, Mode, LastUsedAt, UpdatedAt,
+            EnvFile, EnvProfiles, Limits, PidFile, WrittenPidFile,
+            NetworkCheck, Category, ColorRules
+        FROM projects
         WHERE Name = ?
     `
+
+	findByID = `
+        SELECT Id, Name, Command, Path, TearDown, PathPrepend,
+            StoppedCheck, StoppedPort, Env, CleanEnv, WaitFor,
+            LastExitCode, LastRunAt, CreatedAt, Commands, ContinueOnError,
+            Notes, VerifyCommand, Host, Scripts, Detach, Pid,
+            TearDownOnInterrupt, DependsOn, Mode, LastUsedAt, UpdatedAt,
+            EnvFile, EnvProfiles, Limits, PidFile, WrittenPidFile,
+            NetworkCheck, Category, ColorRules
+        FROM projects
+        WHERE Id = ?
+    `
+
+	findAll = `
+        SELECT Id, Name, Command, Path, TearDown, PathPrepend,
+            StoppedCheck, StoppedPort, Env, CleanEnv, WaitFor,
+            LastExitCode, LastRunAt, CreatedAt, Commands, ContinueOnError,
+            Notes, VerifyCommand, Host, Scripts, Detach, Pid,
+            TearDownOnInterrupt, DependsOn, Mode, LastUsedAt, UpdatedAt,
+            EnvFile, EnvProfiles, Limits, PidFile, WrittenPidFile,
+            NetworkCheck, Category, ColorRules
+        FROM projects
+        ORDER BY Name
+    `
+
+	setPid = `
+        UPDATE projects
+        SET Pid = ?
+        WHERE Id = ?
+    `
+
+	touchProject = `
+        UPDATE projects
+        SET LastUsedAt = ?
+        WHERE Id = ?
+    `
+
+	recordRun = `
+        UPDATE projects
+        SET LastExitCode = ?, LastRunAt = ?
+        WHERE Id = ?
+    `
+
+	remove = `
+        DELETE FROM projects
+        WHERE Id = ?
+    `
+
+	// Names pick the project for every command, so they have to be unique.
+	// The oldest project keeps a duplicated name; the others get their ID
+	// appended, to be renamed as their owner sees fit.
+	dedupeNames = `
+        UPDATE projects SET Name = Name || '-' || substr(Id, 1, 8)
+        WHERE rowid NOT IN (SELECT MIN(rowid) FROM projects GROUP BY Name)
+    `
+
+	// What dedupeNames is about to rename, old name first.
+	duplicateNames = `
+        SELECT Name, Name || '-' || substr(Id, 1, 8) FROM projects
+        WHERE rowid NOT IN (SELECT MIN(rowid) FROM projects GROUP BY Name)
+        ORDER BY Name, rowid
+    `
 )
 
+// Schema migrations, applied in order on top of the base table. The
+// database's user_version records how many have already been run.
+var migrations = []string{
+	`ALTER TABLE projects ADD COLUMN PathPrepend TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN StoppedCheck TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN StoppedPort INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE projects ADD COLUMN Env TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN CleanEnv BOOLEAN NOT NULL DEFAULT 0`,
+	`ALTER TABLE projects ADD COLUMN WaitFor TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN LastExitCode INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE projects ADD COLUMN LastRunAt DATETIME`,
+	`ALTER TABLE projects ADD COLUMN Commands TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN ContinueOnError BOOLEAN NOT NULL DEFAULT 0`,
+	`ALTER TABLE projects ADD COLUMN Notes TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN VerifyCommand TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN Host TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN Scripts TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE project_tags(
+        ProjectId TEXT NOT NULL,
+        Tag TEXT NOT NULL,
+        PRIMARY KEY (ProjectId, Tag)
+    )`,
+	`CREATE INDEX project_tags_tag ON project_tags(Tag)`,
+	`ALTER TABLE projects ADD COLUMN Detach BOOLEAN NOT NULL DEFAULT 0`,
+	`ALTER TABLE projects ADD COLUMN Pid INTEGER NOT NULL DEFAULT 0`,
+	dedupeNames,
+	`CREATE UNIQUE INDEX projects_name ON projects(Name)`,
+	`ALTER TABLE projects ADD COLUMN TearDownOnInterrupt BOOLEAN NOT NULL DEFAULT 0`,
+	`ALTER TABLE projects ADD COLUMN DependsOn TEXT NOT NULL DEFAULT ''`,
+	// DurationMs is NULL for starts that weren't timed, like detached ones.
+	`CREATE TABLE project_runs(
+        ProjectId TEXT NOT NULL,
+        StartedAt DATETIME NOT NULL,
+        DurationMs INTEGER,
+        ExitCode INTEGER NOT NULL
+    )`,
+	`CREATE INDEX project_runs_project ON project_runs(ProjectId)`,
+	`CREATE TABLE project_aliases(
+        Alias TEXT PRIMARY KEY,
+        ProjectId TEXT NOT NULL
+    )`,
+	`ALTER TABLE projects ADD COLUMN Mode TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN LastUsedAt DATETIME`,
+	`ALTER TABLE projects ADD COLUMN UpdatedAt DATETIME`,
+	`UPDATE projects SET UpdatedAt = CreatedAt`,
+	`ALTER TABLE projects ADD COLUMN EnvFile TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN EnvProfiles TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN Limits TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN PidFile TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN WrittenPidFile TEXT NOT NULL DEFAULT ''`,
+	// The project's name is kept, so events outlive its removal or renaming.
+	`CREATE TABLE project_events(
+        Id INTEGER PRIMARY KEY AUTOINCREMENT,
+        ProjectId TEXT NOT NULL,
+        Project TEXT NOT NULL,
+        Event TEXT NOT NULL,
+        At DATETIME NOT NULL,
+        ExitCode INTEGER NOT NULL
+    )`,
+	`ALTER TABLE projects ADD COLUMN NetworkCheck TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN Category TEXT NOT NULL DEFAULT ''`,
+	`CREATE INDEX projects_category ON projects(Category)`,
+	// Labels given to a start with --label, as an encoded map.
+	`ALTER TABLE project_runs ADD COLUMN Labels TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE project_events ADD COLUMN Labels TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE projects ADD COLUMN ColorRules TEXT NOT NULL DEFAULT ''`,
+}
+
 var cursor = "==>"
 
-// cliError - Returns an error and exits with code 1.
+// exit - How cliError ends the run, replaced by tests to check failures.
+var exit = os.Exit
+
+// cliError - Print an error and exit, with the code errorExitCode picks.
 func cliError(err error) {
+	cleanUp()
 	color.Red(fmt.Sprintf("%s Error: %s\n", cursor, err.Error()))
-	os.Exit(1)
+	exit(errorExitCode(err))
+}
+
+// cleanUp - Show held back output, and stop the pager and profiling, as
+// deferred calls would, before exiting early.
+func cleanUp() {
+	if flushQuiet != nil {
+		flushQuiet()
+	}
+	if stopPager != nil {
+		stopPager()
+	}
+	if stopProfile != nil {
+		stopProfile()
+	}
 }
 
 func cliSuccessOut(output string) {
 	color.Green(fmt.Sprintf("%s %s", cursor, output))
 }
 
+func cliWarn(output string) {
+	color.Yellow(fmt.Sprintf("%s Warning: %s", cursor, output))
+}
+
 func cliOut(output string) {
 	color.Blue(fmt.Sprintf("%s %s", cursor, output))
 }
 
+// confirm - Ask a yes/no question on stdin, defaulting to no.
+func confirm(question string) bool {
+
+	fmt.Printf("%s %s [y/N] ", cursor, question)
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
+
+// holdInterrupts - Hold off Ctrl-C and SIGTERM while a write is in progress.
+// Call the returned function once the write is done; if a signal arrived in
+// the meantime, proj exits then, rather than part way through.
+func holdInterrupts() func() {
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	return func() {
+		signal.Stop(signals)
+
+		select {
+		case sig := <-signals:
+			cliError(fmt.Errorf("Interrupted (%s), after finishing the current write.", sig))
+		default:
+		}
+	}
+}
+
+// cliJSON - Print v as indented JSON, for --output=json.
+func cliJSON(v interface{}) {
+
+	data, err := json.MarshalIndent(v, "", "  ")
+
+	if err != nil {
+		cliError(err)
+	}
+
+	fmt.Println(string(data))
+}
+
 func cliStreamOut(message chan string) {
 	cliOut(<-message)
 }
@@ -102,25 +651,131 @@ func cliStreamOut(message chan string) {
 // Proj - Main project instance.
 type Proj struct {
 	db *sql.DB
+
+	// Serialises writes, so concurrent saves and updates can't interleave.
+	mu sync.Mutex
 }
 
 // NewProj - New instance of Proj app.
 func NewProj(db *sql.DB) *Proj {
-	return &Proj{db}
+	return &Proj{db: db}
 }
 
 // Project - Project object
 type Project struct {
-	ID       string `yaml:"id"`
-	Name     string `yaml:"name"`
-	Path     string `yaml:"path"`
-	Command  string `yaml:"command"`
-	TearDown string `yaml:"tear_down"`
+	ID       string `yaml:"id" json:"id"`
+	Name     string `yaml:"name" json:"name"`
+	Path     string `yaml:"path" json:"path"`
+	Command  string `yaml:"command" json:"command"`
+	TearDown string `yaml:"tear_down" json:"tear_down"`
+
+	// Further boot commands, run in order after Command. By default the
+	// first failure skips the rest; ContinueOnError runs them regardless.
+	Commands        []string `yaml:"commands,omitempty" json:"commands,omitempty"`
+	ContinueOnError bool     `yaml:"continue_on_error,omitempty" json:"continue_on_error,omitempty"`
+
+	// Directories prepended to PATH when running commands. Relative
+	// entries are resolved against Path.
+	PathPrepend []string `yaml:"path_prepend,omitempty" json:"path_prepend,omitempty"`
+
+	// Checked after tear down to confirm the project really stopped. The
+	// command should succeed only while the project is still running, and
+	// the port should no longer accept connections.
+	StoppedCheck string `yaml:"stopped_check,omitempty" json:"stopped_check,omitempty"`
+	StoppedPort  int    `yaml:"stopped_port,omitempty" json:"stopped_port,omitempty"`
+
+	// Extra environment variables for commands. With CleanEnv set, commands
+	// get only these and PATH, rather than inheriting proj's environment.
+	Env      map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	CleanEnv bool              `yaml:"clean_env,omitempty" json:"clean_env,omitempty"`
+
+	// External host:port endpoints that must accept connections before
+	// the start command runs.
+	WaitFor []string `yaml:"wait_for,omitempty" json:"wait_for,omitempty"`
+
+	// Free-form notes, e.g. links, reminders or who owns the project.
+	Notes string `yaml:"notes,omitempty" json:"notes,omitempty"`
+
+	// Side-effect free command `proj verify` runs to check the project can
+	// start, e.g. `docker-compose config -q`.
+	VerifyCommand string `yaml:"verify_command,omitempty" json:"verify_command,omitempty"`
+
+	// Remote host, as [user@]host, to run commands on over ssh. Path is
+	// then a directory on that host. Empty means run locally.
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
+
+	// Named tasks, run with `proj do <script>`, e.g. test: npm test.
+	Scripts map[string]string `yaml:"scripts,omitempty" json:"scripts,omitempty"`
+
+	// Labels for grouping projects. Stored lower case, in their own table.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// Run the boot commands in the background, logging to a file, rather
+	// than waiting on them.
+	Detach bool `yaml:"detach,omitempty" json:"detach,omitempty"`
+
+	// Run the tear down when a foreground start is interrupted.
+	TearDownOnInterrupt bool `yaml:"teardown_on_interrupt,omitempty" json:"teardown_on_interrupt,omitempty"`
+
+	// Names of the projects this one needs.
+	DependsOn []string `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+
+	// How commands run: "shell" (the default) through the shell, or "exec"
+	// directly, split into words like the shell would but without any
+	// expansion.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// A .env style file, relative to the project's path, whose values
+	// override Env. Named sets of env values, picked with
+	// start --env-profile, override both.
+	EnvFile     string                       `yaml:"env_file,omitempty" json:"env_file,omitempty"`
+	EnvProfiles map[string]map[string]string `yaml:"env_profiles,omitempty" json:"env_profiles,omitempty"`
+
+	// Memory and CPU caps for the project's commands, or nil for none.
+	Limits *Limits `yaml:"limits,omitempty" json:"limits,omitempty"`
+
+	// File a detached start writes its process ID to, for other tools to
+	// watch. Relative to the project's path.
+	PidFile string `yaml:"pid_file,omitempty" json:"pid_file,omitempty"`
+
+	// host:port that must be reachable before the project starts, for
+	// projects that need the internet, e.g. to pull images.
+	NetworkCheck string `yaml:"network_check,omitempty" json:"network_check,omitempty"`
+
+	// Where the project is filed, e.g. client-acme or client-acme/api: a
+	// category, and optionally a subcategory within it.
+	Category string `yaml:"category,omitempty" json:"category,omitempty"`
+
+	// Colors for output lines matching a regex, e.g. ERROR in red. The
+	// first rule a line matches wins.
+	ColorRules []ColorRule `yaml:"color_rules,omitempty" json:"color_rules,omitempty"`
+
+	// Outcome of the last start, kept in the database only. LastRunAt is
+	// nil if the project has never been started.
+	LastExitCode int        `yaml:"-" json:"last_exit_code"`
+	LastRunAt    *time.Time `yaml:"-" json:"last_run_at,omitempty"`
+
+	CreatedAt time.Time `yaml:"-" json:"created_at"`
+	UpdatedAt time.Time `yaml:"-" json:"updated_at"`
+
+	// When the project was last started or touched, or nil if never.
+	// Kept in the database only.
+	LastUsedAt *time.Time `yaml:"-" json:"last_used_at,omitempty"`
+
+	// Process ID of a detached start, or 0, and the pid file it wrote, to
+	// remove when it stops. Kept in the database only.
+	Pid            int    `yaml:"-" json:"pid,omitempty"`
+	WrittenPidFile string `yaml:"-" json:"written_pid_file,omitempty"`
+
+	// The working copy's state, only looked up for --git.
+	Git *GitInfo `yaml:"-" json:"git,omitempty"`
 }
 
 // InitDB - Initialise database.
 func InitDB(filepath string) *sql.DB {
-	db, err := sql.Open("sqlite3", filepath)
+
+	// Wait on a locked database rather than failing straight away.
+	db, err := sql.Open("sqlite3", filepath+"?_busy_timeout=5000")
 
 	if err != nil {
 		cliError(errors.New("Could not create database."))
@@ -130,215 +785,1768 @@ func InitDB(filepath string) *sql.DB {
 		cliError(errors.New("DB Not found!"))
 	}
 
+	// sql.Open is lazy, so make sure the file can really be opened.
+	if err := retryDB(db.Ping); err != nil {
+		cliError(fmt.Errorf("Could not open database %s: %s", filepath, err))
+	}
+
 	return db
 }
 
 // CreateTable - Create table if not exists.
 func CreateTable(db *sql.DB) {
-	_, err := db.Exec(table)
+
+	err := retryDB(func() error {
+		_, err := db.Exec(table)
+		return err
+	})
+
 	if err != nil {
-		cliError(errors.New("Failed to create database table."))
+		cliError(fmt.Errorf("Failed to create database table: %s", err))
 	}
 }
 
-// SaveProject - Save a project to the database.
-func (proj *Proj) SaveProject(project Project) {
+// How many times retryDB tries, and its first backoff. The delay doubles
+// each time, so it gives up after well under a second.
+const (
+	dbAttempts = 5
+	dbBackoff  = 50 * time.Millisecond
+)
 
-	project.ID = uuid.NewV4().String()
+// retryDB - Run fn, retrying with backoff while it fails with a transient
+// SQLite error, e.g. from a slow or network filesystem.
+func retryDB(fn func() error) error {
+
+	delay := dbBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+
+		if err == nil || !transientDBError(err) || attempt == dbAttempts {
+			return err
+		}
 
-	stmt, err := proj.db.Prepare(add)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
 
-	defer stmt.Close()
+// reportRenames - Warn about each project dedupeNames is about to rename.
+func reportRenames(db *sql.DB) {
 
-	_, err = stmt.Exec(project.ID, project.Name, project.Path, project.Command, project.TearDown)
+	rows, err := db.Query(duplicateNames)
 
 	if err != nil {
-		cliError(errors.New("Failed to save project."))
+		cliError(fmt.Errorf("Failed to find duplicate project names: %s", err))
 	}
 
-	cliOut("Saved to database.")
+	defer rows.Close()
+
+	for rows.Next() {
+		var old, renamed string
+
+		if err := rows.Scan(&old, &renamed); err != nil {
+			cliError(fmt.Errorf("Failed to find duplicate project names: %s", err))
+		}
+
+		cliWarn(fmt.Sprintf("Renamed %s to %s, as an older project is also called %s. Change it with proj edit %s if you like.", old, renamed, old, renamed))
+	}
 }
 
-// UpdateProject - Update a project in the database.
-func (proj *Proj) UpdateProject(project Project) {
+// MigrateDB - Apply any schema migrations the database hasn't seen yet.
+func MigrateDB(db *sql.DB) {
 
-	stmt, err := proj.db.Prepare(update)
+	version, err := schemaVersion(db)
 
 	if err != nil {
-		cliError(errors.New("Failed to update project."))
+		cliError(err)
 	}
 
-	defer stmt.Close()
+	// Migrations only go forwards, so a database a newer proj has used
+	// may have columns and tables this one knows nothing about.
+	if version > len(migrations) {
+		cliWarn(fmt.Sprintf("The database's schema is version %d, but this proj only knows up to %d. It was last used by a newer proj; upgrade, or expect errors.", version, len(migrations)))
+	}
 
-	_, err = stmt.Exec(project.Name, project.Command, project.Path, project.TearDown, project.ID)
+	for i := version; i < len(migrations); i++ {
+		// Otherwise a renamed project would quietly stop answering to its
+		// name.
+		if migrations[i] == dedupeNames {
+			reportRenames(db)
+		}
 
-	if err != nil {
-		cliError(errors.New("Failed to update project."))
+		// A column left behind by an older proj means this migration
+		// has, in effect, already been applied.
+		if _, err := db.Exec(migrations[i]); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			cliError(fmt.Errorf("Failed to apply migration %d: %s", i+1, err))
+		}
+
+		// PRAGMA doesn't accept bound parameters.
+		if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			cliError(errors.New("Failed to record schema version."))
+		}
 	}
 }
 
-// LoadProject - Load a project from the database.
-func (proj *Proj) LoadProject(name string) Project {
+// encodeList - Encode a list of strings for storage in a TEXT column.
+func encodeList(list []string) string {
+	data, _ := json.Marshal(list)
+	return string(data)
+}
 
-	row := proj.db.QueryRow(find, name)
+// decodeList - Decode a list of strings stored by encodeList.
+func decodeList(data string) []string {
+	var list []string
+	json.Unmarshal([]byte(data), &list)
+	return list
+}
 
-	var project Project
+// encodeMap - Encode a string map for storage in a TEXT column.
+func encodeMap(m map[string]string) string {
+	data, _ := json.Marshal(m)
+	return string(data)
+}
 
-	err := row.Scan(&project.ID, &project.Name, &project.Command, &project.Path, &project.TearDown)
+// encodeLabels - Encode a run's labels for storage, "" for none.
+func encodeLabels(labels map[string]string) string {
 
-	if err != nil {
-		cliError(errors.New("Failed to load project."))
+	if len(labels) == 0 {
+		return ""
 	}
 
-	return project
+	return encodeMap(labels)
 }
 
-func main() {
+// decodeMap - Decode a string map stored by encodeMap.
+func decodeMap(data string) map[string]string {
+	var m map[string]string
+	json.Unmarshal([]byte(data), &m)
+	return m
+}
 
-	const DbPath = "/tmp/projects.db"
+// encodeProfiles - Encode env profiles for storage in a TEXT column.
+func encodeProfiles(profiles map[string]map[string]string) string {
+	data, _ := json.Marshal(profiles)
+	return string(data)
+}
 
-	db := InitDB(DbPath)
-	defer db.Close()
-	CreateTable(db)
+// decodeProfiles - Decode env profiles stored by encodeProfiles.
+func decodeProfiles(data string) map[string]map[string]string {
+	var profiles map[string]map[string]string
+	json.Unmarshal([]byte(data), &profiles)
+	return profiles
+}
 
-	proj := NewProj(db)
+// encodeLimits - Encode resource limits for storage in a TEXT column, ""
+// for none.
+func encodeLimits(limits *Limits) string {
 
-	switch kingpin.MustParse(app.Parse(os.Args[1:])) {
-	case initProject.FullCommand():
-		project := Project{
-			Name:     *initProjectName,
-			Path:     *initProjectPath,
-			Command:  *initProjectCommand,
-			TearDown: *initProjectTearDown,
-		}
-		proj.InitProject(project)
+	if limits == nil {
+		return ""
+	}
 
-	case commit.FullCommand():
-		cliOut("Updating...")
-		proj.CommitChanges()
+	data, _ := json.Marshal(limits)
+	return string(data)
+}
 
-	case start.FullCommand():
-		cliOut("Starting: " + *startName)
-		proj.StartProject(*startName)
+// decodeLimits - Decode resource limits stored by encodeLimits.
+func decodeLimits(data string) *Limits {
 
-	case stop.FullCommand():
-		cliOut("Stopping: " + *stopName)
-		proj.StopProject(*stopName)
+	if data == "" {
+		return nil
+	}
+
+	var limits Limits
+	json.Unmarshal([]byte(data), &limits)
+	return &limits
+}
+
+// projectValues - A project's values, in the column order of add.
+func projectValues(project Project) []interface{} {
+	return []interface{}{
+		project.ID,
+		project.Name,
+		project.Path,
+		project.Command,
+		project.TearDown,
+		encodeList(project.PathPrepend),
+		project.StoppedCheck,
+		project.StoppedPort,
+		encodeMap(project.Env),
+		project.CleanEnv,
+		encodeList(project.WaitFor),
+		encodeList(project.Commands),
+		project.ContinueOnError,
+		project.Notes,
+		project.VerifyCommand,
+		project.Host,
+		encodeMap(project.Scripts),
+		project.Detach,
+		project.TearDownOnInterrupt,
+		encodeList(project.DependsOn),
+		project.Mode,
+		project.EnvFile,
+		encodeProfiles(project.EnvProfiles),
+		encodeLimits(project.Limits),
+		project.PidFile,
+		project.NetworkCheck,
+		project.Category,
+		encodeColorRules(project.ColorRules),
 	}
 }
 
-// InitProject - Create new project.
-func (proj *Proj) InitProject(project Project) {
+// inTx - Run fn in a transaction, committing only if it succeeds. An
+// interrupted or failed write leaves the database as it was.
+func (proj *Proj) inTx(fn func(tx *sql.Tx) error) error {
 
-	// Create a YAML file from project details.
-	go proj.CreateProjectFile(project)
-	go proj.SaveProject(project)
+	tx, err := proj.db.Begin()
 
-	cliOut("Saved project: " + project.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// StartProject - Start a project.
-func (proj *Proj) StartProject(name string) {
+// insertProject - Add a project and its tags, as part of a write
+// transaction. Restored projects keep their original ID.
+func insertProject(tx *sql.Tx, project Project) error {
 
-	// Load project
-	project := proj.LoadProject(name)
+	if project.ID == "" {
+		project.ID = uuid.NewV4().String()
+	}
+
+	if _, err := tx.Exec(add, projectValues(project)...); err != nil {
+		return err
+	}
+
+	return saveTags(tx, project.ID, project.Tags)
+}
 
-	// Run start command
-	cmd := exec.Command("sh", "-c", project.Command, project.Path)
+// SaveProject - Save a project to the database.
+func (proj *Proj) SaveProject(project Project) {
+	proj.saveProject(project, "")
+}
 
-	// Stdout buffer
-	cmdOutput := &bytes.Buffer{}
+// saveProject - Save a project to the database. If staged names a project
+// file written aside, it's moved into place as the last step of the
+// transaction: if that fails, the project isn't saved, and if saving fails
+// after it, the file is removed again.
+func (proj *Proj) saveProject(project Project, staged string) {
 
-	// Attach buffer to command
-	cmd.Stdout = cmdOutput
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
 
-	// Execute command
-	printCommand(cmd)
+	proj.checkNotAlias(project.Name)
 
-	err := cmd.Run() // will wait for command to return
+	file := filepath.Join(project.Dir(), config.projectFile())
+
+	var placed bool
+	var placeErr error
+
+	err := proj.inTx(func(tx *sql.Tx) error {
+		if err := insertProject(tx, project); err != nil || staged == "" {
+			return err
+		}
+
+		if placeErr = os.Rename(staged, file); placeErr != nil {
+			return placeErr
+		}
+
+		placed = true
+		return nil
+	})
+
+	// cliError exits, so clean up here rather than in a deferred call.
+	if err != nil && placed {
+		os.Remove(file)
+	} else if err != nil && staged != "" {
+		os.Remove(staged)
+	}
+
+	if duplicateName(err) {
+		cliError(projectExistsError(project.Name))
+	}
+
+	if placeErr != nil {
+		cliError(fmt.Errorf("Failed to create the project file, so the project wasn't saved: %s", placeErr))
+	}
 
 	if err != nil {
-		cliError(err)
+		cliError(errors.New("Failed to save project."))
 	}
 
-	// Only output the commands stdout
-	printOutput(cmdOutput.Bytes())
+	cliOut("Saved to database.")
 }
 
-// StopProject - Stops a project. @todo - this is almost identical to the start project function.
-func (proj *Proj) StopProject(name string) {
+// UpdateProject - Update a project in the database.
+func (proj *Proj) UpdateProject(project Project) {
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
 
-	// Load project.
-	project := proj.LoadProject(name)
+	proj.checkNotAlias(project.Name)
 
-	// Run start command
-	cmd := exec.Command("sh", "-c", project.TearDown, project.Path)
+	// The update sets every column but the ID, which picks the row.
+	values := append(projectValues(project)[1:], project.ID)
 
-	// Stdout buffer
-	cmdOutput := &bytes.Buffer{}
+	err := proj.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(update, values...); err != nil {
+			return err
+		}
+		return saveTags(tx, project.ID, project.Tags)
+	})
 
-	// Attach buffer to command
-	cmd.Stdout = cmdOutput
+	if duplicateName(err) {
+		cliError(projectExistsError(project.Name))
+	}
 
-	// Execute command
-	printCommand(cmd)
+	if err != nil {
+		cliError(errors.New("Failed to update project."))
+	}
+}
 
-	err := cmd.Run() // will wait for command to return
+// DeleteProject - Remove a project from the database.
+func (proj *Proj) DeleteProject(project Project) {
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	err := proj.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(remove, project.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(clearRuns, project.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(clearAliases, project.ID); err != nil {
+			return err
+		}
+		return saveTags(tx, project.ID, nil)
+	})
 
 	if err != nil {
-		cliError(err)
+		cliError(errors.New("Failed to delete project."))
+	}
+}
+
+// RecordRun - Store the exit code of a project's start, as its last run,
+// and add it to the run history. A zero duration means the run wasn't
+// timed, e.g. a detached start that carries on in the background. Labels
+// from --label go with it.
+func (proj *Proj) RecordRun(project Project, code int, started time.Time, duration time.Duration, labels map[string]string) {
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	var ms interface{}
+	if duration > 0 {
+		ms = int64(duration / time.Millisecond)
 	}
 
-	// Only output the commands stdout
-	printOutput(cmdOutput.Bytes())
+	err := proj.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(recordRun, code, time.Now(), project.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(addRun, project.ID, started, ms, code, encodeLabels(labels)); err != nil {
+			return err
+		}
+
+		event := eventStarted
+		if code != 0 {
+			event = eventFailed
+		}
+
+		_, err := tx.Exec(addEvent, project.ID, project.Name, event, time.Now(), code, encodeLabels(labels))
+		return err
+	})
+
+	if err != nil {
+		cliError(errors.New("Failed to record run."))
+	}
 }
 
-func printCommand(cmd *exec.Cmd) {
-	color.Magenta("%s Executing: %s\n", cursor, strings.Join(cmd.Args, " "))
+// Touch - Mark a project as just used, for list --sort=recent.
+func (proj *Proj) Touch(project Project) {
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	if _, err := proj.db.Exec(touchProject, time.Now(), project.ID); err != nil {
+		cliError(errors.New("Failed to touch project."))
+	}
 }
 
-func printOutput(outs []byte) {
-	if len(outs) > 0 {
-		color.Blue("%s Output: %s\n", cursor, string(outs))
+// LoadProject - Load a project from the database.
+func (proj *Proj) LoadProject(name string) Project {
+
+	project, err := proj.findProject(name)
+
+	if err == sql.ErrNoRows {
+		cliError(proj.unknownProject(name))
+	}
+
+	if err != nil {
+		cliError(errors.New("Failed to load project."))
 	}
+
+	return project
 }
 
-// CreateProjectFile - Create a project file.
-func (proj *Proj) CreateProjectFile(project Project) {
+// findProject - Load a project by name or alias, with sql.ErrNoRows if
+// there's no such project, for callers that mustn't exit.
+func (proj *Proj) findProject(name string) (Project, error) {
 
-	// Save a yaml file
-	data, err := yaml.Marshal(&project)
+	project, err := scanProject(proj.db.QueryRow(find, name))
+
+	if err == sql.ErrNoRows {
+		project, err = proj.loadAliased(name)
+	}
 
 	if err != nil {
-		cliError(err)
+		return Project{}, err
 	}
 
-	err = ioutil.WriteFile(project.Path+"/proj.yml", data, 0755)
+	projects := []Project{project}
+	proj.loadTags(projects)
+
+	return projects[0], nil
+}
+
+// LoadProjectByID - Load a project by its ID, which stays the same across
+// renames.
+func (proj *Proj) LoadProjectByID(id string) Project {
+
+	project, err := scanProject(proj.db.QueryRow(findByID, id))
 
 	if err != nil {
-		cliError(err)
+		cliError(newProjectError(ErrProjectNotFound, "No project has the ID %s.", id))
 	}
 
-	cliOut("Created config file.")
+	projects := []Project{project}
+	proj.loadTags(projects)
+
+	return projects[0]
 }
 
-// CommitChanges - Commit file changes to the database.
-func (proj *Proj) CommitChanges() {
+// ListProjects - Load every project from the database, ordered by name.
+func (proj *Proj) ListProjects() []Project {
+
+	rows, err := proj.db.Query(findAll)
+
+	if err != nil {
+		cliError(errors.New("Failed to load projects."))
+	}
+
+	defer rows.Close()
+
+	projects := []Project{}
+
+	for rows.Next() {
+		project, err := scanProject(rows)
+
+		if err != nil {
+			cliError(errors.New("Failed to load projects."))
+		}
+
+		projects = append(projects, project)
+	}
+
+	proj.loadTags(projects)
+
+	return projects
+}
+
+// scanner - Satisfied by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProject - Scan a row selected by find or findAll into a Project.
+func scanProject(row scanner) (Project, error) {
 
 	var project Project
+	var pathPrepend, env, waitFor, commands, scripts, dependsOn, profiles, limits, colorRules string
+	var lastRunAt, createdAt, lastUsedAt, updatedAt sql.NullTime
 
-	// Load yaml file
-	data, err := ioutil.ReadFile("./proj.yml")
+	err := row.Scan(&project.ID, &project.Name, &project.Command, &project.Path, &project.TearDown, &pathPrepend, &project.StoppedCheck, &project.StoppedPort, &env, &project.CleanEnv, &waitFor, &project.LastExitCode, &lastRunAt, &createdAt, &commands, &project.ContinueOnError, &project.Notes, &project.VerifyCommand, &project.Host, &scripts, &project.Detach, &project.Pid, &project.TearDownOnInterrupt, &dependsOn, &project.Mode, &lastUsedAt, &updatedAt, &project.EnvFile, &profiles, &limits, &project.PidFile, &project.WrittenPidFile, &project.NetworkCheck, &project.Category, &colorRules)
 
 	if err != nil {
-		cliError(err)
+		return project, err
 	}
 
-	err = yaml.Unmarshal(data, &project)
+	project.PathPrepend = decodeList(pathPrepend)
+	project.Env = decodeMap(env)
+	project.WaitFor = decodeList(waitFor)
+	project.Commands = decodeList(commands)
+	project.Scripts = decodeMap(scripts)
+	project.DependsOn = decodeList(dependsOn)
+	project.EnvProfiles = decodeProfiles(profiles)
+	project.Limits = decodeLimits(limits)
+	project.ColorRules = decodeColorRules(colorRules)
+	if lastRunAt.Valid {
+		project.LastRunAt = &lastRunAt.Time
+	}
 
-	if err != nil {
-		cliError(err)
+	project.CreatedAt = createdAt.Time
+	project.UpdatedAt = updatedAt.Time
+
+	if lastUsedAt.Valid {
+		project.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return project, nil
+}
+
+func main() {
+
+	config = LoadConfig()
+
+	switch config.Color {
+	case "always":
+		color.NoColor = false
+	case "never":
+		color.NoColor = true
+	default:
+		if colorUnsupported() {
+			color.NoColor = true
+		}
+	}
+
+	command := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	if *noColor {
+		color.NoColor = true
 	}
 
-	go proj.UpdateProject(project)
+	if *cpuProfile != "" || *traceFile != "" {
+		defer startProfiling(*cpuProfile, *traceFile)()
+	}
+
+	// Runs as long as a detached project, so leaves the database alone.
+	if command == logWriter.FullCommand() {
+		LogWriter(*logWriterPath)
+		return
+	}
+
+	// Checked before opening the database would create an empty one.
+	if command == migrateConfig.FullCommand() && !oldDBFound() {
+		return
+	}
+
+	db := InitDB(config.dbPath())
+	defer db.Close()
+	CreateTable(db)
+
+	// Repair reports on the migrations it applies itself.
+	if command != repair.FullCommand() {
+		MigrateDB(db)
+	}
+
+	proj := NewProj(db)
+
+	if proj.Welcomed(command) {
+		return
+	}
+
+	switch command {
+	case initProject.FullCommand():
+		project := Project{
+			ID:                  *initProjectID,
+			Name:                *initProjectName,
+			ContinueOnError:     *initProjectContinue,
+			Notes:               *initProjectNotes,
+			VerifyCommand:       *initProjectVerify,
+			Host:                *initProjectHost,
+			Scripts:             *initProjectScripts,
+			Tags:                *initProjectTags,
+			Detach:              *initProjectDetach,
+			TearDownOnInterrupt: *initProjectTDInterrupt,
+			DependsOn:           *initProjectDependsOn,
+			Mode:                *initProjectMode,
+			EnvFile:             *initProjectEnvFile,
+			PidFile:             *initProjectPidFile,
+			NetworkCheck:        *initProjectNetwork,
+			Category:            *initProjectCategory,
+			TearDown:            *initProjectTearDown,
+			PathPrepend:         *initProjectPathPrepend,
+			StoppedCheck:        *initProjectStopCheck,
+			StoppedPort:         *initProjectStopPort,
+			Env:                 *initProjectEnv,
+			CleanEnv:            *initProjectCleanEnv,
+			WaitFor:             *initProjectWaitFor,
+		}
+		if *initProjectPath != "" {
+			project.Path = resolveProjectPath(*initProjectPath, *initProjectHost)
+		}
+		if err := validateCategory(project.Category); err != nil {
+			cliError(err)
+		}
+		for _, rule := range *initProjectColorRules {
+			parsed, err := parseColorRule(rule)
+			if err != nil {
+				cliError(err)
+			}
+			project.ColorRules = append(project.ColorRules, parsed)
+		}
+		if _, err := compileColorRules(project.ColorRules); err != nil {
+			cliError(err)
+		}
+		if *initProjectMemory != "" || *initProjectCPU != "" {
+			project.Limits = &Limits{Memory: *initProjectMemory, CPU: *initProjectCPU}
+			if err := project.Limits.validate(); err != nil {
+				cliError(err)
+			}
+		}
+		if len(*initProjectCommand) > 0 {
+			project.Command = (*initProjectCommand)[0]
+			project.Commands = (*initProjectCommand)[1:]
+		}
+		if *initProjectStdin {
+			if len(*initProjectCommand) > 0 {
+				cliError(errors.New("Give either --command or --command-stdin, not both."))
+			}
+			project.Command = readScript()
+		}
+
+		// Given flags win over anything detected.
+		if *initProjectDetect {
+			project = detectProject(project)
+		}
+
+		// Re-running an init script updates what it created the first time.
+		if *initProjectUpdate && proj.projectExists(project.Name) {
+			proj.ReinitProject(project)
+			break
+		}
+
+		missing := project.Name == "" || project.Path == "" || project.Command == ""
+
+		// Without the required flags, a terminal gets the wizard instead.
+		if *initProjectInteractive || (missing && isTerminal()) {
+			project = proj.PromptProject(project)
+		} else if missing {
+			cliError(errors.New("--name, --path and --command are required, or use --interactive."))
+		}
+		proj.InitProject(project)
+
+	case commit.FullCommand():
+		cliOut("Updating...")
+		proj.CommitChanges()
+
+	case start.FullCommand():
+		opts := StartOptions{
+			WaitFor:     *startWait,
+			WaitTimeout: *startWaitTimeout,
+			Record:      *startRecord,
+			Cast:        *startCast,
+			Tee:         *startTee,
+			PrintEnv:    *startPrintEnv,
+			DryRun:      *startDryRun,
+			Detach:      *startDetach,
+			Until:       *startUntil,
+			Report:      *startReport,
+			Locked:      *startLocked,
+			Exec:        *startExec,
+			EnvProfile:  *startEnvProfile,
+			Env:         *startEnv,
+			EnvSources:  *startEnvSources,
+			Health:      *startHealth,
+			Dir:         *startDir,
+			Quiet:       *startQuiet,
+			Network:     *startNetwork,
+			Labels:      *startLabels,
+			MaxRuntime:  *startMaxRuntime,
+		}
+		if *startOnExit == "restart" {
+			if *startMaxRestarts < 1 {
+				cliError(errors.New("--max-restarts must be at least 1."))
+			}
+			opts.Restart = &RestartPolicy{Max: *startMaxRestarts, Backoff: *startBackoff, Always: *startAlways}
+		} else if *startAlways {
+			cliError(errors.New("--always is for --on-exit=restart."))
+		}
+		if *startPidFile != "" {
+			path, err := filepath.Abs(*startPidFile)
+			if err != nil {
+				cliError(err)
+			}
+			opts.PidFile = path
+		}
+		if opts.Dir != "" && (*startAll || *startCategory != "") {
+			cliError(errors.New("--dir is for a single project."))
+		}
+		if opts.Health > 0 && opts.Until != nil {
+			cliError(errors.New("Give either --health-retries or --until, not both."))
+		}
+		if opts.Report != "" && (*startAll || *startCategory != "" || opts.Detach || opts.Until != nil) {
+			cliError(errors.New("--report is for a single project started in the foreground."))
+		}
+		name := proj.nameOrID(*startName, *startID, *startAll)
+		var names []string
+		if *startCategory != "" {
+			if name != "" || *startAll {
+				cliError(errors.New("Give a project name, --all or --category, only one of them."))
+			}
+			if len(*startOnly) > 0 || len(*startSkip) > 0 {
+				cliError(errors.New("--only and --skip can only be used with --all."))
+			}
+			names = proj.categoryProjects(*startCategory)
+		} else {
+			names = proj.selectProjects(name, *startAll, *startOnly, *startSkip)
+		}
+		for _, name := range names {
+			if *startReplace {
+				proj.Replace(name, opts.DryRun)
+			}
+			cliOut("Starting: " + name)
+			proj.StartProject(name, opts)
+		}
+		if !opts.DryRun {
+			proj.autoPruneLogs()
+		}
+
+	case do.FullCommand():
+		proj.Do(*doName, *doNames, *doAll, *doOnly, *doSkip, *doJobs)
+
+	case execAll.FullCommand():
+		proj.ExecAll(*execAllCommand, *execAllTag, *execAllOnly, *execAllSkip, *execAllJobs)
+
+	case last.FullCommand():
+		name := lastProject()
+		cliOut("Starting: " + name)
+		proj.StartProject(name, StartOptions{WaitTimeout: defaultWaitTimeout})
+
+	case ensure.FullCommand():
+		proj.Ensure(*ensureName, StartOptions{
+			WaitTimeout: defaultWaitTimeout,
+			Detach:      *ensureDetach,
+			Until:       *ensureUntil,
+		})
+
+	case stop.FullCommand():
+		if *stopReport != "" && *stopAll {
+			cliError(errors.New("--report is for a single project."))
+		}
+		if *stopReport != "" && *stopSignal != "" {
+			cliError(errors.New("--report is for tear downs, not --signal."))
+		}
+		name := proj.nameOrID(*stopName, *stopID, *stopAll)
+		if *stopSignal != "" {
+			sig, err := parseSignal(*stopSignal)
+			if err != nil {
+				cliError(err)
+			}
+			for _, name := range proj.selectProjects(name, *stopAll, *stopOnly, *stopSkip) {
+				proj.SignalProject(name, sig)
+			}
+			break
+		}
+		if *stopForce && *stopCascade {
+			cliError(errors.New("Give either --force or --cascade, not both."))
+		}
+		proj.StopProjects(proj.selectProjects(name, *stopAll, *stopOnly, *stopSkip), StopOptions{
+			Report:  *stopReport,
+			Force:   *stopForce,
+			Cascade: *stopCascade,
+			NoWarn:  *stopNoWarn,
+		})
+
+	case edit.FullCommand():
+		command := ""
+		if *editStdin {
+			command = readScript()
+		}
+		proj.EditProject(*editName, *editNotes, command)
+
+	case stats.FullCommand():
+		proj.Stats(*statsSince, *statsSort)
+
+	case pruneLogs.FullCommand():
+		policy := config.logPolicy()
+		if *pruneLogsMaxAge > 0 {
+			policy.MaxAge = *pruneLogsMaxAge
+		}
+		if *pruneLogsMaxSize != "" {
+			size, err := parseMemory(*pruneLogsMaxSize)
+			if err != nil {
+				cliError(errors.New("--max-size must be a size like 500M or 2G."))
+			}
+			policy.MaxSize = size
+		}
+		proj.PruneLogs(policy, *pruneLogsDryRun)
+
+	case diffEnvCommand.FullCommand():
+		proj.DiffEnv(*diffEnvName)
+
+	case validate.FullCommand():
+		Validate(*validatePaths, *validateFix, *validateYes)
+
+	case infoCommand.FullCommand():
+		proj.Info()
+
+	case autoStop.FullCommand():
+		proj.AutoStop(*autoStopName, *autoStopPid, *autoStopAfter)
+
+	case checkDepsCommand.FullCommand():
+		proj.CheckDeps()
+
+	case events.FullCommand():
+		proj.Events(*eventsSince, *eventsFollow)
+
+	case touch.FullCommand():
+		project := proj.LoadProject(*touchName)
+		proj.Touch(project)
+		cliSuccessOut("Touched " + project.Name)
+
+	case cat.FullCommand():
+		proj.Cat(*catName, *catFromDB)
+
+	case lock.FullCommand():
+		proj.LockProject(*lockName)
+
+	case verify.FullCommand():
+		proj.VerifyProject(*verifyName)
+
+	case which.FullCommand():
+		proj.Which(*whichName, *whichTearDown)
+
+	case list.FullCommand():
+		state := ""
+		switch {
+		case *listRunning && *listStopped:
+			cliError(errors.New("--running and --stopped can't be used together."))
+		case *listRunning:
+			state = "running"
+		case *listStopped:
+			state = "stopped"
+		}
+		defer startPager()()
+		proj.List(*listFormat, *listColumns, *listTag, *listCategory, state, *listSort, *listReverse, *listGit, *listGroup)
+
+	case tagAdd.FullCommand():
+		proj.TagAdd(*tagAddName, *tagAddTag)
+
+	case tagRemove.FullCommand():
+		proj.TagRemove(*tagRemoveName, *tagRemoveTag)
+
+	case tagRename.FullCommand():
+		proj.TagRename(*tagRenameOld, *tagRenameNew)
+
+	case aliasAdd.FullCommand():
+		proj.AliasAdd(*aliasAddAlias, *aliasAddName)
+
+	case aliasRemove.FullCommand():
+		proj.AliasRemove(*aliasRemoveAlias)
+
+	case aliasList.FullCommand():
+		proj.AliasList()
+
+	case secretSet.FullCommand():
+		SecretSet(*secretSetName, *secretSetValue)
+
+	case secretGet.FullCommand():
+		SecretGet(*secretGetName)
+
+	case secretRemove.FullCommand():
+		SecretRemove(*secretRemoveName)
+
+	case logs.FullCommand():
+		if !*logsFollow {
+			defer startPager()()
+		}
+		proj.Logs(*logsName, LogOptions{
+			Follow:     *logsFollow,
+			Tail:       *logsTail,
+			Since:      *logsSince,
+			Timestamps: *logsTimes,
+		})
+
+	case deps.FullCommand():
+		proj.Deps(*depsName, *depsAll, *depsDot)
+
+	case show.FullCommand():
+		name := proj.nameOrID(*showName, *showID, false)
+		if name == "" {
+			cliError(errors.New("Give a project name, or --id."))
+		}
+		defer startPager()()
+		proj.Show(name, *showGit)
+
+	case status.FullCommand():
+		proj.Status()
+
+	case configGet.FullCommand():
+		ConfigGet(*configGetKey)
+
+	case configSet.FullCommand():
+		ConfigSet(*configSetKey, *configSetValue)
+
+	case configList.FullCommand():
+		ConfigList()
+
+	case snapshot.FullCommand():
+		proj.Snapshot(*snapshotFile)
+
+	case repair.FullCommand():
+		proj.Repair()
+
+	case moveDB.FullCommand():
+		proj.MoveDB(*moveDBPath, *moveDBForce, *moveDBRemoveOld)
+
+	case migrateConfig.FullCommand():
+		proj.MigrateConfig(*migrateConfigClean)
+
+	case restore.FullCommand():
+		proj.Restore(*restoreFile, *restoreYes)
+
+	case archive.FullCommand():
+		proj.ArchiveProject(*archiveName, *archiveDir)
+
+	case importCommand.FullCommand():
+		proj.Import(*importFiles, *importPartial, *importMakefile)
+
+	case unarchive.FullCommand():
+		proj.UnarchiveProject(*unarchiveFile)
+
+	case serve.FullCommand():
+		proj.Serve(*serveAddr, *serveToken)
+
+	case completion.FullCommand():
+		switch {
+		case *completionListProjects:
+			proj.CompletionList("projects", *completionProject)
+		case *completionListTags:
+			proj.CompletionList("tags", *completionProject)
+		case *completionListProfiles:
+			proj.CompletionList("profiles", *completionProject)
+		case *completionListScripts:
+			proj.CompletionList("scripts", *completionProject)
+		default:
+			CompletionScript(*completionShell, *completionDynamic)
+		}
+	}
+}
+
+// overrideDir - The directory for start --dir. Local ones are resolved
+// against the working directory and must exist; remote ones are left to
+// the remote shell.
+func overrideDir(dir, host string) string {
+
+	if host != "" {
+		return dir
+	}
+
+	abs, err := filepath.Abs(dir)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		cliError(fmt.Errorf("%s isn't a directory.", abs))
+	}
+
+	return abs
+}
+
+// resolveProjectPath - Make a project path absolute, resolving relative paths
+// against the configured project_root, or the working directory. Remote
+// paths are left to the remote shell.
+func resolveProjectPath(path, host string) string {
+
+	// Paths starting with a variable, like $HOME/code, are kept as written
+	// and expanded each time they're used.
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "$") || host != "" {
+		return path
+	}
+
+	if config.ProjectRoot != "" {
+		return filepath.Join(config.ProjectRoot, path)
+	}
+
+	abs, err := filepath.Abs(path)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	return abs
+}
+
+// validateProject - Check a project has what every project needs, and
+// that its settings make sense, before it's added or updated.
+func validateProject(project Project) error {
+
+	switch {
+	case project.Name == "":
+		return errors.New("A name is required.")
+	case project.Path == "":
+		return errors.New("A path is required.")
+	case project.Command == "":
+		return errors.New("A command is required.")
+	case project.Mode != "" && project.Mode != "shell" && project.Mode != "exec":
+		return fmt.Errorf("Unknown mode %q, expected shell or exec.", project.Mode)
+	case project.TearDownOnInterrupt && project.TearDown == "":
+		return errors.New("teardown_on_interrupt needs a tear down command.")
+	}
+
+	if err := project.Limits.validate(); err != nil {
+		return err
+	}
+
+	if err := validateCategory(project.Category); err != nil {
+		return err
+	}
+
+	_, err := compileColorRules(project.ColorRules)
+
+	return err
+}
+
+// InitProject - Create new project.
+func (proj *Proj) InitProject(project Project) {
+
+	if err := validateProject(project); err != nil {
+		cliError(invalidProject(err))
+	}
+
+	// Let the file and database writes finish, even if interrupted.
+	release := holdInterrupts()
+	defer release()
+
+	// Checked first, so an existing project's proj.yml isn't overwritten.
+	if proj.projectExists(project.Name) {
+		cliError(projectExistsError(project.Name))
+	}
+
+	proj.checkNotAlias(project.Name)
+
+	project.warnTearDown()
+
+	if project.ID != "" && proj.projectIDExists(project.ID) {
+		cliError(newProjectError(ErrProjectExists, "There's already a project with the ID %s.", project.ID))
+	}
+
+	// Assign the ID up front, so the YAML file and database agree.
+	if project.ID == "" {
+		project.ID = uuid.NewV4().String()
+	}
+
+	// The YAML file is written aside, and only moved into place as part of
+	// the database write, so a failure in either leaves neither behind.
+	// Remote projects' paths aren't on this machine, so they only live in
+	// the database.
+	var staged string
+
+	if project.Host == "" {
+		var err error
+
+		if staged, err = stageProjectFile(project); err != nil {
+			cliError(err)
+		}
+	}
+
+	proj.saveProject(project, staged)
+
+	if staged != "" {
+		cliOut("Created config file.")
+	}
+
+	cliOut("Saved project: " + project.Name)
+}
+
+// StartOptions - Settings for a single StartProject call, on top of the project's own.
+type StartOptions struct {
+	// Extra host:port endpoints to wait for, and how long to wait.
+	WaitFor     []string
+	WaitTimeout time.Duration
+
+	// File to record the run to, for sharing, and an asciinema cast of it.
+	Record string
+	Cast   string
+
+	// File to append the output to, as it's shown live.
+	Tee string
+
+	// Print the resolved environment first, and with DryRun, stop there
+	// rather than running anything.
+	PrintEnv bool
+	DryRun   bool
+
+	// Start in the background, even if the project doesn't usually.
+	Detach bool
+
+	// Stream output until a line matches, then leave it in the background.
+	Until *regexp.Regexp
+
+	// Write a JSON summary of the run to this file.
+	Report string
+
+	// Refuse to start if the project differs from its proj.lock.
+	Locked bool
+
+	// Run the commands in exec mode, even if the project uses the shell.
+	Exec bool
+
+	// Env layered over the project's own and its env file: a profile, then
+	// values from the command line. With EnvSources, the result is printed
+	// with where each value came from.
+	EnvProfile string
+	Env        map[string]string
+	EnvSources bool
+
+	// After a detached start, how many times to check it's ready.
+	Health int
+
+	// Pid file to write instead of the project's own.
+	PidFile string
+
+	// Directory to run in instead of the project's path, for this start
+	// only, e.g. another worktree of the same repo.
+	Dir string
+
+	// Hold back the commands and their output, printing them only if the
+	// start fails.
+	Quiet bool
+
+	// Check the network is up first, even if the project doesn't usually.
+	Network bool
+
+	// Restart the project when it exits, or nil to leave it.
+	Restart *RestartPolicy
+
+	// Labels for this run only, e.g. a ticket or build ID, to find it by
+	// in the run history, events and report. The project doesn't keep them.
+	Labels map[string]string
+
+	// Once detached, how long until it's stopped, or 0 to leave it.
+	MaxRuntime time.Duration
+}
+
+// StartProject - Start a project.
+func (proj *Proj) StartProject(name string, opts StartOptions) {
+
+	// Load project
+	project := proj.LoadProject(name)
+
+	if opts.Dir != "" {
+		project.Path = overrideDir(opts.Dir, project.Host)
+	}
+
+	if opts.Locked {
+		checkLock(project)
+	}
+
+	if opts.Exec {
+		project.Mode = "exec"
+	}
+
+	if opts.Health > 0 && !opts.Detach && !project.Detach {
+		cliError(errors.New("--health-retries is for detached starts."))
+	}
+
+	if opts.MaxRuntime < 0 || (opts.MaxRuntime > 0 && !opts.Detach && !project.Detach && opts.Until == nil) {
+		cliError(errors.New("--max-runtime is for detached starts, and must be positive."))
+	}
+
+	if opts.Quiet && (opts.Detach || project.Detach || opts.Until != nil) {
+		cliError(errors.New("--quiet-on-success is for starts in the foreground."))
+	}
+
+	if opts.Cast != "" && (opts.Detach || project.Detach || opts.Until != nil) {
+		cliError(errors.New("--record-asciinema is for starts in the foreground."))
+	}
+
+	if opts.Tee != "" && (opts.Detach || project.Detach || opts.Until != nil) {
+		cliError(errors.New("--tee is for starts in the foreground, detached output goes to the log."))
+	}
+
+	if opts.PidFile != "" {
+		if !opts.Detach && !project.Detach && opts.Until == nil {
+			cliError(errors.New("--pid-file is for detached starts."))
+		}
+		project.PidFile = opts.PidFile
+	}
+
+	project, sources, err := project.withEnvLayers(opts.EnvProfile, opts.Env)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	if opts.EnvSources {
+		printEnvSources(project, sources)
+	}
+
+	if opts.PrintEnv {
+		printEnv(project.ResolvedEnv())
+	}
+
+	if opts.DryRun {
+		for _, command := range project.StartCommands() {
+			printCommand(projectCommand(project, command))
+		}
+		return
+	}
+
+	// Remember it for `proj last`.
+	config.LastProject = project.Name
+	SaveConfig(config)
+
+	proj.Touch(project)
+
+	if opts.Network || project.NetworkCheck != "" {
+		if err := checkNetwork(project.NetworkCheck); err != nil {
+			cliError(err)
+		}
+	}
+
+	waitFor(append(project.WaitFor, opts.WaitFor...), opts.WaitTimeout)
+
+	commands := project.StartCommands()
+	started := time.Now()
+
+	// In the background, the restarts are left to the shell, as proj exits.
+	if opts.Restart != nil && (opts.Detach || project.Detach || opts.Until != nil) {
+		supervised, err := supervisedCommand(project, commands, *opts.Restart)
+
+		if err != nil {
+			cliError(err)
+		}
+
+		commands = []string{supervised}
+	}
+
+	if opts.Until != nil {
+		err := proj.startUntil(project, commands, opts.Until)
+
+		proj.RecordRun(project, exitCode(err), started, time.Since(started), opts.Labels)
+
+		if err != nil {
+			cliError(err)
+		}
+
+		proj.autoStopAfter(project, opts.MaxRuntime)
+		return
+	}
+
+	if opts.Detach || project.Detach {
+		if opts.Health > 0 {
+			err = proj.startHealthy(project, commands, opts.Health)
+		} else {
+			err = proj.startDetached(project, commands)
+		}
+
+		proj.RecordRun(project, exitCode(err), started, 0, opts.Labels)
+
+		if err != nil {
+			cliError(err)
+		}
+
+		if opts.Health > 0 {
+			proj.RecordEvent(project, eventHealthOK, 0, opts.Labels)
+		}
+
+		proj.autoStopAfter(project, opts.MaxRuntime)
+		return
+	}
+
+	var sinks []io.Writer
+	var rec *recorder
+	var cast *castRecorder
+	var report *reporter
+
+	if opts.Record != "" {
+		rec = newRecorder(opts.Record)
+		rec.Header(project, commands)
+		sinks = append(sinks, rec)
+	}
+
+	if opts.Cast != "" {
+		cast = newCastRecorder(opts.Cast, project)
+		sinks = append(sinks, cast)
+	}
+
+	if opts.Report != "" {
+		report = newReporter(opts.Report, "start", project, commands)
+		report.report.Labels = opts.Labels
+		sinks = append(sinks, report)
+	}
+
+	finish := proj.watchInterrupts(project)
+
+	if opts.Quiet {
+		defer holdOutput()()
+	}
+
+	// After holding output back, so the tee's display is held back too.
+	var teed *tee
+
+	if opts.Tee != "" {
+		teed = newTee(opts.Tee, project)
+		sinks = append(sinks, teed)
+	}
+
+	if opts.Restart != nil {
+		err = proj.runSupervised(project, commands, *opts.Restart, sinks...)
+	} else {
+		err = proj.runCommands(project, commands, sinks...)
+	}
+
+	proj.RecordRun(project, exitCode(err), started, time.Since(started), opts.Labels)
+
+	if rec != nil {
+		rec.Finish(exitCode(err))
+	}
+
+	if cast != nil {
+		cast.Finish()
+	}
+
+	if teed != nil {
+		teed.Close()
+	}
+
+	if report != nil {
+		report.Finish(err)
+	}
+
+	finish()
+
+	if err != nil {
+		cliError(err)
+	}
+}
+
+// StartCommands - Every boot command, in the order they run.
+func (project Project) StartCommands() []string {
+
+	var commands []string
+
+	if project.Command != "" {
+		commands = append(commands, project.Command)
+	}
+
+	return append(commands, project.Commands...)
+}
+
+// warnTearDown - Warn when the tear down is the start command. Stopping
+// would run the blocking command again and hang, so it's almost always a
+// mistake, but not one worth refusing.
+func (project Project) warnTearDown() {
+
+	if project.TearDown != "" && project.TearDown == project.Command {
+		cliWarn(project.Name + "'s teardown is the same as its command, so stop would start it again.")
+	}
+}
+
+// runCommands - Run commands in order, stopping at the first failure unless
+// the project continues on error. Returns the first failure.
+func (proj *Proj) runCommands(project Project, commands []string, sinks ...io.Writer) error {
+
+	var failed error
+
+	for i, command := range commands {
+		if atomic.LoadInt32(&interrupted) != 0 {
+			break
+		}
+
+		err := proj.runCommand(project, command, sinks...)
+
+		if err == nil {
+			continue
+		}
+
+		cliWarn(fmt.Sprintf("Command %d of %d failed: %s", i+1, len(commands), err))
+
+		if failed == nil {
+			failed = err
+		}
+
+		if !project.ContinueOnError {
+			if skipped := len(commands) - i - 1; skipped > 0 {
+				cliWarn(fmt.Sprintf("Skipped the remaining %d command(s).", skipped))
+			}
+			break
+		}
+	}
+
+	return failed
+}
+
+// StopOptions - Settings for StopProjects.
+type StopOptions struct {
+	// Write a JSON summary of the tear down to this file.
+	Report string
+
+	// What to do about running projects that depend on one being stopped:
+	// stop it anyway, or stop them first. Otherwise it's refused.
+	Force   bool
+	Cascade bool
+
+	// Don't list the projects skipped for having no tear down.
+	NoWarn bool
+}
+
+// StopProjects - Stop each project, those that depend on others first.
+// Stopping one that running projects depend on is refused, unless forced,
+// or cascaded to stop them too.
+func (proj *Proj) StopProjects(names []string, opts StopOptions) {
+
+	graph := proj.dependencyGraph()
+
+	// Keep just the selected projects, in stopping order.
+	selected := map[string]bool{}
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	var ordered []string
+	for _, name := range stopOrder(graph, names) {
+		if selected[name] {
+			ordered = append(ordered, name)
+		}
+	}
+
+	var skipped []string
+	stopped := map[string]bool{}
+
+	for _, name := range ordered {
+		if stopped[name] {
+			continue
+		}
+
+		var running []string
+		for _, dependent := range dependents(graph, name) {
+			if !stopped[dependent] && upReason(proj.LoadProject(dependent)) != "" {
+				running = append(running, dependent)
+			}
+		}
+
+		switch {
+		case len(running) == 0:
+		case opts.Cascade:
+			for _, dependent := range running {
+				cliOut(fmt.Sprintf("Stopping: %s, which needs %s", dependent, name))
+				proj.StopProject(dependent, "")
+				stopped[dependent] = true
+			}
+		case opts.Force:
+			cliWarn(fmt.Sprintf("Stopping %s, though running projects need it: %s", name, strings.Join(running, ", ")))
+		default:
+			cliError(fmt.Errorf("%s is needed by running projects: %s. Stop them first, pass --cascade to stop them too, or --force.", name, strings.Join(running, ", ")))
+		}
+
+		cliOut("Stopping: " + name)
+		stopped[name] = true
+
+		if !proj.StopProject(name, opts.Report) {
+			skipped = append(skipped, name)
+		}
+	}
+
+	if len(skipped) > 0 && !opts.NoWarn {
+		cliWarn(fmt.Sprintf("Skipped %d project(s) with no tear down and no detached process: %s", len(skipped), strings.Join(skipped, ", ")))
+	}
+}
+
+// StopProject - Stops a project, running its tear down script. Returns
+// false if it was skipped, having no tear down and no detached process.
+func (proj *Proj) StopProject(name, reportPath string) bool {
+
+	// Load project.
+	project := proj.LoadProject(name)
+
+	// Nothing to run or terminate, though a dead process is forgotten.
+	if project.TearDown == "" && !project.Running() {
+		proj.stopDetached(project)
+		return false
+	}
+
+	if project.TearDown != "" {
+		var sinks []io.Writer
+		var report *reporter
+
+		if reportPath != "" {
+			report = newReporter(reportPath, "stop", project, []string{project.TearDown})
+			sinks = append(sinks, report)
+		}
+
+		err := proj.runCommand(project, project.TearDown, sinks...)
+
+		if report != nil {
+			report.Finish(err)
+		}
+
+		if err != nil {
+			proj.RecordEvent(project, eventFailed, exitCode(err), nil)
+			cliError(err)
+		}
+	}
+
+	// Detached processes the tear down didn't deal with are terminated.
+	proj.stopDetached(project)
+
+	proj.RecordEvent(project, eventStopped, 0, nil)
+
+	proj.checkStopped(project)
+
+	return true
+}
+
+// checkStopped - Warn if the project still appears to be up after tear down.
+func (proj *Proj) checkStopped(project Project) {
+
+	if reason := stillUp(project); reason != "" {
+		cliWarn(project.Name + " still appears to be running, " + reason + ".")
+	}
+}
+
+// stillUp - Why the project's stopped check or port say it's running, or
+// "" if they don't.
+func stillUp(project Project) string {
+
+	if project.StoppedCheck != "" {
+		cmd := projectCommand(project, project.StoppedCheck)
+
+		// Success means whatever the check looks for is still there.
+		if cmd.Run() == nil {
+			return "stopped check passed"
+		}
+	}
+
+	if project.StoppedPort != 0 {
+		addr := net.JoinHostPort(project.hostname(), strconv.Itoa(project.StoppedPort))
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+
+		if err == nil {
+			conn.Close()
+			return fmt.Sprintf("port %d is in use", project.StoppedPort)
+		}
+	}
+
+	return ""
+}
+
+// projectCommand - Build a shell command that runs from the project's directory.
+func projectCommand(project Project, command string) *exec.Cmd {
+
+	// Commands run outside of a start still get the env file.
+	project, _, err := project.withEnvLayers("", nil)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	command, err = expandCommand(project, command)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	return expandedCommand(project, command)
+}
+
+// expandedCommand - Build the command for one whose templates have already
+// been filled in.
+func expandedCommand(project Project, command string) *exec.Cmd {
+
+	project, err := project.withTimes().withSecrets()
+
+	if err != nil {
+		cliError(err)
+	}
+
+	if project.Host != "" && project.Limits != nil {
+		warnLimits("Resource limits aren't applied to remote projects.")
+	}
+
+	if project.Mode == "exec" {
+		cmd, err := execCommand(project, command)
+
+		if err != nil {
+			cliError(err)
+		}
+
+		if project.Host != "" {
+			return cmd
+		}
+
+		return withLimits(cmd, project.Limits)
+	}
+
+	if project.Host != "" {
+		return remoteCommand(project, command)
+	}
+
+	cmd := shellCommand(config.shell(), command)
+	cmd.Dir = project.Dir()
+	cmd.Env = project.Environ()
+
+	return withLimits(cmd, project.Limits)
+}
+
+// runCommand - Run a shell command from the project's directory. Any sinks
+// get a copy of its stdout and stderr as it runs.
+func (proj *Proj) runCommand(project Project, command string, sinks ...io.Writer) error {
+
+	cmd := projectCommand(project, command)
+
+	// Stdout buffer, keeping only the tail of long output
+	cmdOutput := newRingBuffer(maxCapturedOutput)
+
+	// Attach buffer to command, normalized as configured
+	stdout := jsonLogWriter(cmdOutput)
+	cmd.Stdout = outputWriter(io.MultiWriter(append([]io.Writer{stdout}, sinks...)...))
+
+	if len(sinks) > 0 {
+		cmd.Stderr = outputWriter(io.MultiWriter(sinks...))
+	}
+
+	// Execute command
+	printCommand(cmd)
+
+	err := commandFailed(cmd.Run()) // will wait for command to return
+
+	// Before the writers below, so a held back CR or byte reaches them.
+	flush(cmd.Stdout)
+	flush(cmd.Stderr)
+
+	flush(stdout)
+
+	// Don't let a partial last line run into the next command's output.
+	for _, sink := range sinks {
+		if f, ok := sink.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+	}
+
+	if dropped := cmdOutput.Truncated(); dropped > 0 {
+		cliWarn(fmt.Sprintf("Output truncated, %d bytes dropped.", dropped))
+	}
+
+	// Only output the commands stdout, unless it's been shown as it ran
+	if !showsOutput(sinks) {
+		printOutput(cmdOutput.Bytes(), project)
+	}
+
+	return err
+}
+
+// exitCode - The exit code for an error returned by running a command.
+func exitCode(err error) int {
+
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	// The command couldn't be run at all.
+	return -1
+}
+
+func printCommand(cmd *exec.Cmd) {
+	color.Magenta("%s Executing: %s\n", cursor, strings.Join(cmd.Args, " "))
+}
+
+// printOutput - Print a command's captured output, with lines matching the
+// project's color rules in their colors, and the rest in blue.
+func printOutput(outs []byte, project Project) {
+
+	if len(outs) == 0 {
+		return
+	}
+
+	w := highlightWriter(color.Output, project)
+
+	h, ok := w.(*highlighter)
+
+	if !ok {
+		color.Blue("%s Output: %s\n", cursor, string(outs))
+		return
+	}
+
+	h.plain = color.New(color.FgBlue)
+
+	fmt.Fprint(color.Output, color.BlueString("%s Output: ", cursor))
+	h.Write(outs)
+	h.Flush()
+	fmt.Fprintln(color.Output)
+}
+
+// CreateProjectFile - Create a project file.
+func (proj *Proj) CreateProjectFile(project Project) {
+
+	// Save a yaml file
+	data, err := yaml.Marshal(&project)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(project.Dir(), config.projectFile()), data, 0755)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	cliOut("Created config file.")
+}
+
+// stageProjectFile - Write a project's YAML file next to where it goes,
+// under a temporary name, for saveProject to move into place.
+func stageProjectFile(project Project) (string, error) {
+
+	data, err := yaml.Marshal(&project)
+
+	if err != nil {
+		return "", err
+	}
+
+	staged := filepath.Join(project.Dir(), "."+config.projectFile()+".tmp")
+
+	if err := ioutil.WriteFile(staged, data, 0755); err != nil {
+		return "", err
+	}
+
+	return staged, nil
+}
+
+// CommitChanges - Commit file changes to the database.
+func (proj *Proj) CommitChanges() {
+
+	path, err := findProjectFile(".")
+
+	if err != nil {
+		cliError(err)
+	}
+
+	// Load yaml file, along with anything it includes
+	project, err := readProjectFile(path)
+
+	if err != nil {
+		cliError(invalidProject(err))
+	}
+
+	if err := validateProject(project); err != nil {
+		cliError(invalidProject(err))
+	}
+
+	project.warnTearDown()
+
+	release := holdInterrupts()
+	defer release()
+
+	proj.UpdateProject(project)
 }
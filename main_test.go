@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// newTestProj - A Proj on a fresh database in a temporary directory.
+func newTestProj(t *testing.T) *Proj {
+
+	t.Helper()
+
+	db := InitDB(filepath.Join(t.TempDir(), "projects.db"))
+	t.Cleanup(func() { db.Close() })
+
+	CreateTable(db)
+	MigrateDB(db)
+
+	return NewProj(db)
+}
+
+func TestConcurrentSaves(t *testing.T) {
+
+	proj := newTestProj(t)
+	dir := t.TempDir()
+
+	const saves = 20
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < saves; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			proj.SaveProject(Project{
+				Name:    fmt.Sprintf("project-%d", i),
+				Path:    dir,
+				Command: fmt.Sprintf("echo %d", i),
+				Tags:    []string{fmt.Sprintf("tag-%d", i), "shared"},
+			})
+		}(i)
+	}
+
+	wg.Wait()
+
+	projects := proj.ListProjects()
+
+	if len(projects) != saves {
+		t.Fatalf("got %d projects, want %d", len(projects), saves)
+	}
+
+	for i := 0; i < saves; i++ {
+		project := proj.LoadProject(fmt.Sprintf("project-%d", i))
+
+		if want := fmt.Sprintf("echo %d", i); project.Command != want {
+			t.Errorf("%s has the command %q, want %q", project.Name, project.Command, want)
+		}
+
+		if len(project.Tags) != 2 {
+			t.Errorf("%s has the tags %v, want 2", project.Name, project.Tags)
+		}
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+
+	proj := newTestProj(t)
+
+	proj.SaveProject(Project{Name: "api", Path: t.TempDir(), Command: "echo start"})
+	saved := proj.LoadProject("api")
+
+	const updates = 20
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			project := saved
+			project.Command = fmt.Sprintf("echo %d", i)
+			project.TearDown = fmt.Sprintf("echo %d", i)
+			project.Tags = []string{fmt.Sprintf("tag-%d", i)}
+
+			proj.UpdateProject(project)
+		}(i)
+	}
+
+	wg.Wait()
+
+	project := proj.LoadProject("api")
+
+	// Whichever update won, it won with all of its fields.
+	if project.Command != project.TearDown {
+		t.Errorf("command %q and tear down %q are from different updates", project.Command, project.TearDown)
+	}
+
+	if len(project.Tags) != 1 || project.Tags[0] != "tag-"+project.Command[len("echo "):] {
+		t.Errorf("tags %v are from a different update than the command %q", project.Tags, project.Command)
+	}
+
+	if n := len(proj.ListProjects()); n != 1 {
+		t.Errorf("got %d projects, want 1", n)
+	}
+}
+
+// exited - Stands in for os.Exit in tests, carrying the code.
+type exited int
+
+// expectExit - Run fn, which should fail through cliError, and return the
+// code it would have exited with.
+func expectExit(t *testing.T, fn func()) (code int) {
+
+	t.Helper()
+
+	exit = func(code int) { panic(exited(code)) }
+	defer func() { exit = os.Exit }()
+
+	defer func() {
+		r := recover()
+
+		if r == nil {
+			t.Fatal("expected a failure, but there wasn't one")
+		}
+
+		e, ok := r.(exited)
+
+		if !ok {
+			panic(r)
+		}
+
+		code = int(e)
+	}()
+
+	fn()
+
+	return 0
+}
+
+// countRows - How many projects the database holds.
+func countRows(t *testing.T, proj *Proj) int {
+
+	t.Helper()
+
+	var n int
+
+	if err := proj.db.QueryRow(countProjects).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+// leftovers - The names of files left in dir.
+func leftovers(t *testing.T, dir string) []string {
+
+	t.Helper()
+
+	entries, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+func TestInitProjectUnwritableDir(t *testing.T) {
+
+	proj := newTestProj(t)
+
+	// A file where the directory should be, which even root can't write in.
+	path := filepath.Join(t.TempDir(), "not-a-dir")
+
+	if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expectExit(t, func() {
+		proj.InitProject(Project{Name: "api", Path: path, Command: "echo"})
+	})
+
+	if n := countRows(t, proj); n != 0 {
+		t.Errorf("got %d projects saved, want 0", n)
+	}
+
+	if names := leftovers(t, filepath.Dir(path)); len(names) != 1 {
+		t.Errorf("got files %v left behind, want only not-a-dir", names)
+	}
+}
+
+func TestSaveProjectDuplicateName(t *testing.T) {
+
+	proj := newTestProj(t)
+	dir := t.TempDir()
+	other := t.TempDir()
+
+	proj.SaveProject(Project{Name: "api", Path: other, Command: "echo"})
+
+	// As if another proj saved the name between InitProject's check and
+	// the insert.
+	project := Project{ID: "dup", Name: "api", Path: dir, Command: "echo"}
+	staged, err := stageProjectFile(project)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := expectExit(t, func() { proj.saveProject(project, staged) })
+
+	if code != exitExists {
+		t.Errorf("exited with %d, want %d", code, exitExists)
+	}
+
+	if n := countRows(t, proj); n != 1 {
+		t.Errorf("got %d projects saved, want 1", n)
+	}
+
+	if names := leftovers(t, dir); len(names) != 0 {
+		t.Errorf("got files %v left behind, want none", names)
+	}
+}
+
+func TestSaveProjectFailedRename(t *testing.T) {
+
+	proj := newTestProj(t)
+	dir := t.TempDir()
+
+	// The staged file's gone, so moving it into place fails.
+	project := Project{ID: "gone", Name: "api", Path: dir, Command: "echo"}
+	staged := filepath.Join(dir, "missing.tmp")
+
+	expectExit(t, func() { proj.saveProject(project, staged) })
+
+	if n := countRows(t, proj); n != 0 {
+		t.Errorf("got %d projects saved, want 0", n)
+	}
+
+	if names := leftovers(t, dir); len(names) != 0 {
+		t.Errorf("got files %v left behind, want none", names)
+	}
+}
+
+func TestUpdateProjectDuplicateName(t *testing.T) {
+
+	proj := newTestProj(t)
+
+	proj.SaveProject(Project{Name: "api", Path: t.TempDir(), Command: "echo"})
+	proj.SaveProject(Project{Name: "web", Path: t.TempDir(), Command: "echo"})
+
+	project := proj.LoadProject("web")
+	project.Name = "api"
+
+	if code := expectExit(t, func() { proj.UpdateProject(project) }); code != exitExists {
+		t.Errorf("exited with %d, want %d", code, exitExists)
+	}
+}
+
+func TestUnknownProject(t *testing.T) {
+
+	proj := newTestProj(t)
+
+	if code := expectExit(t, func() { proj.LoadProject("nope") }); code != exitNotFound {
+		t.Errorf("exited with %d, want %d", code, exitNotFound)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Names make finds without being told, so the scripts needn't pass -f.
+var defaultMakefiles = map[string]bool{
+	"GNUmakefile": true,
+	"makefile":    true,
+	"Makefile":    true,
+}
+
+// readMakefile - A project for the Makefile at file, named after its
+// directory. Each target becomes a script running `make <target>`, and up
+// and down targets become the boot and tear down commands. Without an up
+// target, the boot command runs the default goal.
+func readMakefile(file string) ([]importEntry, error) {
+
+	data, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(file))
+
+	if err != nil {
+		return nil, err
+	}
+
+	run := "make"
+	if base := filepath.Base(file); !defaultMakefiles[base] {
+		run = "make -f " + quoteWords([]string{base})
+	}
+
+	project := Project{
+		Name:    filepath.Base(dir),
+		Path:    dir,
+		Command: run,
+		Scripts: map[string]string{},
+	}
+
+	for _, target := range makeTargets(data) {
+		command := run + " " + target
+		project.Scripts[target] = command
+
+		switch target {
+		case "up":
+			project.Command = command
+		case "down":
+			project.TearDown = command
+		}
+	}
+
+	if len(project.Scripts) == 0 {
+		return nil, fmt.Errorf("no targets found")
+	}
+
+	return []importEntry{{source: file, project: project}}, nil
+}
+
+// makeTargets - The task targets a Makefile defines, sorted. If it marks
+// any as .PHONY, only those count, as the rest build files; otherwise every
+// explicit target does. Special targets like .PHONY itself, pattern rules
+// and targets named by variables are left out.
+func makeTargets(data []byte) []string {
+
+	var targets []string
+	phony := map[string]bool{}
+	inDefine := false
+
+	for _, line := range makeLines(data) {
+		trimmed := strings.TrimSpace(line)
+
+		// Recipes, comments, and the bodies of multi-line variables.
+		switch {
+		case inDefine:
+			inDefine = trimmed != "endef"
+			continue
+		case strings.HasPrefix(trimmed, "define ") || trimmed == "define":
+			inDefine = true
+			continue
+		case strings.HasPrefix(line, "\t") || strings.HasPrefix(trimmed, "#"):
+			continue
+		}
+
+		colon := strings.Index(line, ":")
+
+		// Not a rule, or a variable assignment such as A := b or A = b:c.
+		if colon < 0 || strings.HasPrefix(strings.TrimLeft(line[colon:], ":"), "=") || strings.Contains(line[:colon], "=") {
+			continue
+		}
+
+		names := strings.Fields(line[:colon])
+
+		if len(names) == 1 && names[0] == ".PHONY" {
+			prerequisites := strings.TrimLeft(line[colon:], ":")
+			for _, name := range strings.Fields(stripComment(prerequisites)) {
+				phony[name] = true
+			}
+			continue
+		}
+
+		for _, name := range names {
+			if !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, "%$") {
+				targets = append(targets, name)
+			}
+		}
+	}
+
+	// Phony targets may be declared but defined in an included file.
+	if len(phony) > 0 {
+		targets = targets[:0]
+		for name := range phony {
+			if !strings.ContainsAny(name, "%$") {
+				targets = append(targets, name)
+			}
+		}
+	}
+
+	sort.Strings(targets)
+
+	unique := targets[:0]
+	for i, target := range targets {
+		if i == 0 || target != targets[i-1] {
+			unique = append(unique, target)
+		}
+	}
+
+	return unique
+}
+
+// makeLines - A Makefile's logical lines, with backslash continuations
+// joined on.
+func makeLines(data []byte) []string {
+
+	var lines []string
+	var joined string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if strings.HasSuffix(line, "\\") {
+			joined += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+
+		lines = append(lines, joined+line)
+		joined = ""
+	}
+
+	if joined != "" {
+		lines = append(lines, joined)
+	}
+
+	return lines
+}
+
+// stripComment - line up to any # comment.
+func stripComment(line string) string {
+
+	if i := strings.Index(line, "#"); i >= 0 {
+		return line[:i]
+	}
+
+	return line
+}
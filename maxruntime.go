@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// How often auto-stop checks the project is still running, so it doesn't
+// outlive a project that's stopped or crashed first.
+const autoStopPoll = 5 * time.Second
+
+// autoStopAfter - Schedule a just detached project to be stopped after
+// maxRuntime, if it's set. The project's already running by now, so a
+// failure is warned about rather than failing the start.
+func (proj *Proj) autoStopAfter(project Project, maxRuntime time.Duration) {
+
+	if maxRuntime <= 0 {
+		return
+	}
+
+	// Reloaded for the pid the start recorded.
+	if err := scheduleAutoStop(proj.LoadProject(project.Name), maxRuntime); err != nil {
+		cliWarn(fmt.Sprintf("Failed to schedule the auto-stop, stop %s yourself: %s", project.Name, err))
+	}
+}
+
+// scheduleAutoStop - Start proj auto-stop in the background, to stop the
+// project after maxRuntime. It's in a process group of its own, so it
+// isn't caught up in stopping the project, and logs to the project's log
+// through a log writer, as the project does.
+func scheduleAutoStop(project Project, maxRuntime time.Duration) error {
+
+	self, err := os.Executable()
+
+	if err != nil {
+		return err
+	}
+
+	path := logPath(project)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	read, write, err := os.Pipe()
+
+	if err != nil {
+		return err
+	}
+
+	defer read.Close()
+	defer write.Close()
+
+	timer := exec.Command(self, "--no-color", "auto-stop", project.Name,
+		"--pid", strconv.Itoa(project.Pid), "--after", maxRuntime.String())
+	timer.Stdout = write
+	timer.Stderr = write
+	newGroup(timer)
+
+	if err := timer.Start(); err != nil {
+		return err
+	}
+
+	writer := exec.Command(self, "log-writer", path)
+	writer.Stdin = read
+	joinGroup(writer, timer.Process.Pid)
+
+	if err := writer.Start(); err != nil {
+		timer.Process.Kill()
+		return fmt.Errorf("Failed to start the log writer: %s", err)
+	}
+
+	timer.Process.Release()
+	writer.Process.Release()
+
+	cliOut(fmt.Sprintf("%s will be stopped in %s, if it's still running then.", project.Name, maxRuntime))
+
+	return nil
+}
+
+// AutoStop - Stop the project once after has passed, as proj stop would,
+// logging that it's doing so. Run by scheduleAutoStop as proj auto-stop.
+// Gives up if the process with pid exits first, or by then the project
+// is running as another process, having been restarted.
+func (proj *Proj) AutoStop(name string, pid int, after time.Duration) {
+
+	// Outlives the terminal the project was started from.
+	signal.Ignore(syscall.SIGHUP, os.Interrupt)
+
+	deadline := time.NewTimer(after)
+	poll := time.NewTicker(autoStopPoll)
+	defer poll.Stop()
+
+	fired := make(chan struct{})
+
+	go func() {
+		defer close(fired)
+
+		for {
+			select {
+			case <-poll.C:
+				if !processAlive(pid) {
+					deadline.Stop()
+					return
+				}
+			case <-deadline.C:
+				fired <- struct{}{}
+				return
+			}
+		}
+	}()
+
+	if _, ok := <-fired; !ok {
+		return
+	}
+
+	proj.maxRuntimeReached(name, pid, after)
+}
+
+// maxRuntimeReached - Stop the project, if it's still running as pid.
+// Running projects that depend on it don't hold it up, as they would proj
+// stop, since nobody's there to stop them first; they're listed instead.
+func (proj *Proj) maxRuntimeReached(name string, pid int, after time.Duration) {
+
+	if project := proj.LoadProject(name); project.Pid != pid || !project.Running() {
+		return
+	}
+
+	cliWarn(fmt.Sprintf("Max runtime of %s reached, stopping %s, as --force would, even if running projects need it.", after, name))
+
+	proj.StopProjects([]string{name}, StopOptions{Force: true})
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+
+package main
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// startSleeper - Start a process in a group of its own, for a project to
+// run as, killed when the test ends.
+func startSleeper(t *testing.T) *exec.Cmd {
+
+	t.Helper()
+
+	cmd := exec.Command("sleep", "30")
+	newGroup(cmd)
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reaped as soon as it's stopped, so it doesn't linger as a zombie.
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		<-exited
+	})
+
+	return cmd
+}
+
+func TestMaxRuntimeStopsWithRunningDependent(t *testing.T) {
+
+	proj := newTestProj(t)
+
+	proj.SaveProject(Project{Name: "db", Path: t.TempDir(), Command: "sleep 30"})
+	proj.SaveProject(Project{Name: "api", Path: t.TempDir(), Command: "sleep 30", DependsOn: []string{"db"}})
+
+	db := startSleeper(t)
+	api := startSleeper(t)
+
+	proj.SetPid(proj.LoadProject("db"), db.Process.Pid)
+	proj.SetPid(proj.LoadProject("api"), api.Process.Pid)
+
+	proj.maxRuntimeReached("db", db.Process.Pid, time.Hour)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(db.Process.Pid) && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if processAlive(db.Process.Pid) {
+		t.Error("db is still running, though its max runtime was reached")
+	}
+
+	// Forced rather than cascaded, so what needs it is left alone.
+	if !processAlive(api.Process.Pid) {
+		t.Error("api was stopped too")
+	}
+}
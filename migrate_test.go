@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestDedupeNamesReportsRenames(t *testing.T) {
+
+	db := InitDB(filepath.Join(t.TempDir(), "projects.db"))
+	defer db.Close()
+
+	CreateTable(db)
+
+	// The schema as it was just before names had to be unique.
+	for _, migration := range migrations {
+		if migration == dedupeNames {
+			break
+		}
+		if _, err := db.Exec(migration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, id := range []string{"aaaaaaaa-1", "bbbbbbbb-2"} {
+		if _, err := db.Exec("INSERT INTO projects(Id, Name, Path, Command) VALUES(?, 'api', '/srv/api', 'make')", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	saved := color.Output
+	color.Output = &out
+	defer func() { color.Output = saved }()
+
+	reportRenames(db)
+
+	if !strings.Contains(out.String(), "Renamed api to api-bbbbbbbb") {
+		t.Errorf("got %q, want the rename of api to api-bbbbbbbb reported", out.String())
+	}
+
+	if strings.Contains(out.String(), "aaaaaaaa") {
+		t.Errorf("got %q, but the oldest api keeps its name", out.String())
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Where migrate-config moves a database left at defaultDBPath.
+const migratedDBName = "projects.db"
+
+// oldDBFound - Whether there's a database at the old default for
+// migrate-config to move, saying so if not.
+func oldDBFound() bool {
+
+	// MigrateConfig says there's nothing to do.
+	if config.DBPath != "" {
+		return true
+	}
+
+	if _, err := os.Stat(defaultDBPath); os.IsNotExist(err) {
+		cliOut("There's no database at " + defaultDBPath + ", so nothing to migrate. It may have been cleared on reboot.")
+		return false
+	}
+
+	return true
+}
+
+// MigrateConfig - Move a database still at the old default, /tmp, where it
+// can be cleared on reboot, into ~/.proj, writing a config file pointing
+// at it if there isn't one. The old file is kept unless clean is set.
+func (proj *Proj) MigrateConfig(clean bool) {
+
+	if config.DBPath != "" {
+		cliOut("The database is already configured, at " + config.dbPath() + ". Nothing to migrate.")
+		return
+	}
+
+	var projects int
+
+	if err := proj.db.QueryRow(countProjects).Scan(&projects); err != nil {
+		cliError(errors.New("Failed to count projects."))
+	}
+
+	// Likely made by proj itself since a reboot cleared the real one.
+	if projects == 0 {
+		cliOut("The database at " + config.dbPath() + " has no projects, so nothing to migrate.")
+		return
+	}
+
+	target := filepath.Join(projDir(), migratedDBName)
+
+	if _, err := os.Stat(target); err == nil {
+		cliError(errors.New(target + " already exists. Use it with proj config set db_path " + target + ", or move it aside and run this again."))
+	}
+
+	_, err := os.Stat(configPath())
+	created := os.IsNotExist(err)
+
+	_, old := proj.moveDB(target, false)
+
+	cliSuccessOut("Copied the database from " + old + " to " + target)
+
+	if created {
+		cliSuccessOut("Created " + configPath() + ", with db_path set to it.")
+	} else {
+		cliSuccessOut("Set db_path in " + configPath() + " to it.")
+	}
+
+	if !clean {
+		cliOut("The old database is still at " + old + ", pass --clean to delete it.")
+		return
+	}
+
+	removeOldDB(old)
+}
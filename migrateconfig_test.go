@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateConfigEmptyDB(t *testing.T) {
+
+	saved := config
+	defer func() { config = saved }()
+
+	config.DBPath = ""
+	t.Setenv("HOME", t.TempDir())
+
+	proj := newTestProj(t)
+	proj.MigrateConfig(false)
+
+	if _, err := os.Stat(configPath()); !os.IsNotExist(err) {
+		t.Errorf("%s was written for an empty database", configPath())
+	}
+
+	if _, err := os.Stat(filepath.Join(projDir(), migratedDBName)); !os.IsNotExist(err) {
+		t.Error("an empty database was copied")
+	}
+}
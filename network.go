@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Checked when a project doesn't name a host: Docker Hub's registry, as
+// pulling images is the usual reason a start needs the internet.
+const defaultNetworkCheck = "registry-1.docker.io:443"
+
+// How long each of the lookup and the connection get, so being offline
+// fails in seconds.
+const networkCheckTimeout = 3 * time.Second
+
+// checkNetwork - Resolve and connect to addr, a host:port, or the default
+// if it's empty, failing fast with a clear error if the network's down.
+// A host without a port is tried on 443.
+func checkNetwork(addr string) error {
+
+	if addr == "" {
+		addr = defaultNetworkCheck
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		host, port = addr, "443"
+	}
+
+	cliOut("Checking the network can reach " + net.JoinHostPort(host, port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), networkCheckTimeout)
+	defer cancel()
+
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		return fmt.Errorf("No network: couldn't look up %s, %s. Check your connection, or start without the network check.", host, err)
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), networkCheckTimeout)
+
+	if err != nil {
+		return fmt.Errorf("No network: couldn't connect to %s, %s. Check your connection, or start without the network check.", net.JoinHostPort(host, port), err)
+	}
+
+	conn.Close()
+
+	return nil
+}
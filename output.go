@@ -0,0 +1,65 @@
+package main
+
+// Captured command output is capped, so a chatty command can't exhaust memory.
+const maxCapturedOutput = 64 * 1024
+
+// ringBuffer - An io.Writer that keeps only the last size bytes written to it.
+type ringBuffer struct {
+	buf     []byte
+	pos     int
+	full    bool
+	written int64
+}
+
+// newRingBuffer - Create a ring buffer holding up to size bytes.
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{buf: make([]byte, size)}
+}
+
+// Write - Append p, overwriting the oldest bytes once the buffer is full.
+func (r *ringBuffer) Write(p []byte) (int, error) {
+
+	n := len(p)
+	r.written += int64(n)
+
+	// Only the tail of a write bigger than the buffer can survive.
+	if len(p) >= len(r.buf) {
+		copy(r.buf, p[len(p)-len(r.buf):])
+		r.pos = 0
+		r.full = true
+		return n, nil
+	}
+
+	copied := copy(r.buf[r.pos:], p)
+
+	if copied < len(p) {
+		r.pos = copy(r.buf, p[copied:])
+		r.full = true
+	} else {
+		r.pos += copied
+		if r.pos == len(r.buf) {
+			r.pos = 0
+			r.full = true
+		}
+	}
+
+	return n, nil
+}
+
+// Bytes - The retained output, oldest first.
+func (r *ringBuffer) Bytes() []byte {
+
+	if !r.full {
+		return append([]byte(nil), r.buf[:r.pos]...)
+	}
+
+	out := make([]byte, 0, len(r.buf))
+	out = append(out, r.buf[r.pos:]...)
+
+	return append(out, r.buf[:r.pos]...)
+}
+
+// Truncated - How many bytes were dropped from the start of the output.
+func (r *ringBuffer) Truncated() int64 {
+	return r.written - int64(len(r.Bytes()))
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// decoder - Decode as much of p as makes whole characters, returning the
+// text and the bytes left over for the next write.
+type decoder func(p []byte) (string, []byte)
+
+// Charsets output_charset can name, each making a fresh decoder, as some
+// keep state between writes.
+var charsetDecoders = map[string]func() decoder{
+	"latin1":       func() decoder { return decodeLatin1 },
+	"iso-8859-1":   func() decoder { return decodeLatin1 },
+	"windows-1252": func() decoder { return decodeWindows1252 },
+	"cp1252":       func() decoder { return decodeWindows1252 },
+	"utf-16le":     func() decoder { return decodeUTF16(binary.LittleEndian) },
+	"utf-16be":     func() decoder { return decodeUTF16(binary.BigEndian) },
+}
+
+// Where windows-1252 differs from latin1, from 0x80 to 0x9f. The five
+// bytes it leaves undefined become U+FFFD.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// outputNormalizer - An io.Writer that transcodes command output to UTF-8
+// and turns CRLF line endings into LF. Lone CRs, which progress bars
+// redraw lines with, are left alone.
+type outputNormalizer struct {
+	out     io.Writer
+	decode  decoder
+	stripCR bool
+
+	mu      sync.Mutex
+	pending []byte
+	cr      bool
+}
+
+// outputWriter - w, normalizing output as the output_charset and
+// output_strip_cr settings ask. Without them, output passes through as it
+// is. Each stream needs its own, as decoding can span writes.
+func outputWriter(w io.Writer) io.Writer {
+
+	charset := strings.ToLower(config.OutputCharset)
+
+	if (charset == "" || charset == "utf-8") && !config.OutputStripCR {
+		return w
+	}
+
+	normalizer := &outputNormalizer{out: w, stripCR: config.OutputStripCR}
+
+	if newDecoder, ok := charsetDecoders[charset]; ok {
+		normalizer.decode = newDecoder()
+	}
+
+	return normalizer
+}
+
+// Write - Pass on p, decoded and with CRLFs stripped. A partial character,
+// or a CR that may start a CRLF, is held until the next write, or Flush.
+func (o *outputNormalizer) Write(p []byte) (int, error) {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	n := len(p)
+	text := string(p)
+
+	if o.decode != nil {
+		text, o.pending = o.decode(append(o.pending, p...))
+		o.pending = append([]byte(nil), o.pending...)
+	}
+
+	if o.stripCR {
+		if o.cr {
+			text = "\r" + text
+			o.cr = false
+		}
+
+		text = strings.Replace(text, "\r\n", "\n", -1)
+
+		if strings.HasSuffix(text, "\r") {
+			text = text[:len(text)-1]
+			o.cr = true
+		}
+	}
+
+	if _, err := io.WriteString(o.out, text); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// Flush - Pass on anything held back, then flush the writer below. Bytes
+// that never made a whole character become U+FFFD.
+func (o *outputNormalizer) Flush() {
+
+	o.mu.Lock()
+
+	if len(o.pending) > 0 {
+		io.WriteString(o.out, "�")
+		o.pending = nil
+	}
+
+	if o.cr {
+		io.WriteString(o.out, "\r")
+		o.cr = false
+	}
+
+	o.mu.Unlock()
+
+	flush(o.out)
+}
+
+// decodeLatin1 - Each byte is the code point of the same value.
+func decodeLatin1(p []byte) (string, []byte) {
+
+	var text strings.Builder
+
+	for _, b := range p {
+		text.WriteRune(rune(b))
+	}
+
+	return text.String(), nil
+}
+
+// decodeWindows1252 - Latin1, but for the printable characters Windows
+// puts in place of the C1 controls.
+func decodeWindows1252(p []byte) (string, []byte) {
+
+	var text strings.Builder
+
+	for _, b := range p {
+		if b >= 0x80 && b < 0xa0 {
+			text.WriteRune(windows1252[b-0x80])
+		} else {
+			text.WriteRune(rune(b))
+		}
+	}
+
+	return text.String(), nil
+}
+
+// decodeUTF16 - A decoder for UTF-16 in the given byte order. A byte order
+// mark at the start of the output is dropped.
+func decodeUTF16(order binary.ByteOrder) decoder {
+
+	started := false
+
+	return func(p []byte) (string, []byte) {
+
+		var units []uint16
+
+		for len(p) >= 2 {
+			unit := order.Uint16(p)
+
+			// A high surrogate needs the low one after it.
+			if utf16.IsSurrogate(rune(unit)) && unit < 0xdc00 && len(p) < 4 {
+				break
+			}
+
+			units = append(units, unit)
+			p = p[2:]
+		}
+
+		if !started && len(units) > 0 {
+			started = true
+			if units[0] == 0xfeff {
+				units = units[1:]
+			}
+		}
+
+		return string(utf16.Decode(units)), p
+	}
+}
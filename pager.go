@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// Set while output is going through a pager: the terminal it shows on,
+// and how to close it, so cliError can before exiting.
+var (
+	pagedTerminal *os.File
+	stopPager     func()
+)
+
+// startPager - Send stdout through $PAGER, or less, when it's a terminal,
+// as git does. Call the returned function once the output is written; it
+// waits for the pager to be quit. Unless $LESS is set, less is run with
+// FRX: colors pass through, and it exits straight away if the output fits
+// on one screen.
+func startPager() func() {
+
+	if *noPager || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return func() {}
+	}
+
+	pager := os.Getenv("PAGER")
+
+	if pager == "" {
+		if _, err := exec.LookPath("less"); err != nil {
+			return func() {}
+		}
+		pager = "less"
+	}
+
+	if pager == "cat" {
+		return func() {}
+	}
+
+	read, write, err := os.Pipe()
+
+	if err != nil {
+		return func() {}
+	}
+
+	cmd := shellCommand(config.shell(), pager)
+	cmd.Stdin = read
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	if err := cmd.Start(); err != nil {
+		read.Close()
+		write.Close()
+		return func() {}
+	}
+
+	read.Close()
+
+	// Color was decided against the terminal, so it's kept for the pager.
+	stdout, output := os.Stdout, color.Output
+	os.Stdout, color.Output = write, write
+	pagedTerminal = stdout
+
+	stopPager = func() {
+		stopPager, pagedTerminal = nil, nil
+		write.Close()
+		os.Stdout, color.Output = stdout, output
+		cmd.Wait()
+	}
+
+	return stopPager
+}
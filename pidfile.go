@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Pid file SQL statements
+var (
+	setWrittenPidFile = `
+        UPDATE projects
+        SET WrittenPidFile = ?
+        WHERE Id = ?
+    `
+
+	findWrittenPidFile = `
+        SELECT WrittenPidFile FROM projects
+        WHERE Id = ?
+    `
+)
+
+// pidFilePath - Where the project's pid file goes, or "" for none.
+func pidFilePath(project Project) string {
+
+	file := project.PidFile
+
+	if file != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(project.Dir(), file)
+	}
+
+	return file
+}
+
+// checkPidFile - Make way for a new pid file at path. One left behind by a
+// process that's gone, or that doesn't hold a pid, is stale and removed.
+// One whose process is still alive is left alone, and is an error, since
+// something else may be relying on it.
+func checkPidFile(path string) error {
+
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+
+	if err == nil && pid > 0 && processAlive(pid) {
+		return fmt.Errorf("The pid file %s names pid %d, which is still running. Remove it if that's not this project.", path, pid)
+	}
+
+	cliWarn(fmt.Sprintf("Removing stale pid file %s", path))
+
+	return os.Remove(path)
+}
+
+// writePidFile - Write pid to the project's pid file, if it has one, and
+// remember it was written, so it's removed when the project stops. Written
+// to a temporary file first, so watchers never see a partial one.
+func (proj *Proj) writePidFile(project Project, pid int) error {
+
+	path := pidFilePath(project)
+
+	if path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, []byte(strconv.Itoa(pid)+"\n"), 0644); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	if _, err := proj.db.Exec(setWrittenPidFile, path, project.ID); err != nil {
+		return errors.New("Failed to record the pid file.")
+	}
+
+	return nil
+}
+
+// removePidFile - Remove the pid file the project's detached start wrote,
+// if any. Looked up afresh, as callers may hold the project from before it
+// was written.
+func (proj *Proj) removePidFile(project Project) {
+
+	var path string
+
+	if err := proj.db.QueryRow(findWrittenPidFile, project.ID).Scan(&path); err != nil || path == "" {
+		return
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		cliWarn(fmt.Sprintf("Failed to remove the pid file %s: %s", path, err))
+	}
+
+	proj.db.Exec(setWrittenPidFile, "", project.ID)
+}
@@ -0,0 +1,74 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// Shell and editor used when none are configured.
+const (
+	defaultShell  = "sh"
+	defaultEditor = "vi"
+)
+
+// signals - Signals `proj stop --signal` accepts, by name without the SIG
+// prefix.
+var signals = map[string]syscall.Signal{
+	"HUP":   syscall.SIGHUP,
+	"INT":   syscall.SIGINT,
+	"QUIT":  syscall.SIGQUIT,
+	"KILL":  syscall.SIGKILL,
+	"USR1":  syscall.SIGUSR1,
+	"USR2":  syscall.SIGUSR2,
+	"TERM":  syscall.SIGTERM,
+	"CONT":  syscall.SIGCONT,
+	"STOP":  syscall.SIGSTOP,
+	"TSTP":  syscall.SIGTSTP,
+	"WINCH": syscall.SIGWINCH,
+}
+
+// cmdCommand - Run command through cmd. Only Windows has it, but
+// configuring it elsewhere fails when it's run, rather than here.
+func cmdCommand(shell, command string) *exec.Cmd {
+	return exec.Command(shell, "/C", command)
+}
+
+// newGroup - Start cmd in a process group of its own.
+func newGroup(cmd *exec.Cmd) {
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// joinGroup - Start cmd in the process group led by pid.
+func joinGroup(cmd *exec.Cmd, pid int) {
+
+	newGroup(cmd)
+	cmd.SysProcAttr.Pgid = pid
+}
+
+// signalGroup - Send sig to every process in the group led by pid. A group
+// that's already gone isn't an error.
+func signalGroup(pid int, sig syscall.Signal) error {
+
+	if err := syscall.Kill(-pid, sig); err != nil && err != syscall.ESRCH {
+		return err
+	}
+
+	return nil
+}
+
+// processAlive - Whether pid is a live process.
+func processAlive(pid int) bool {
+
+	// Signal 0 checks the process exists, without signalling it.
+	err := syscall.Kill(pid, 0)
+
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
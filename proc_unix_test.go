@@ -0,0 +1,34 @@
+//go:build !windows
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultShellUnix(t *testing.T) {
+
+	if got := (Config{}).shell(); got != "sh" {
+		t.Errorf("default shell = %s, want sh", got)
+	}
+
+	if got := (Config{Shell: "bash"}).shell(); got != "bash" {
+		t.Errorf("configured shell = %s, want bash", got)
+	}
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{defaultShell, []string{"sh", "-c", "echo hi"}},
+		// Only Windows has cmd, but it's still passed /C, to fail when run.
+		{"cmd", []string{"cmd", "/C", "echo hi"}},
+	}
+
+	for _, test := range tests {
+		if got := shellCommand(test.shell, "echo hi").Args; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("shellCommand(%s) runs %q, want %q", test.shell, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Shell and editor used when none are configured.
+const (
+	defaultShell  = "cmd"
+	defaultEditor = "notepad"
+)
+
+// signals - Signals `proj stop --signal` accepts, by name without the SIG
+// prefix. Windows can only end a process, so these all do.
+var signals = map[string]syscall.Signal{
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+}
+
+// Exit code GetExitCodeProcess gives a process that hasn't exited.
+const stillActive = 259
+
+// cmdCommand - Run command through cmd. cmd doesn't unquote its arguments
+// the way Go quotes them, so the command line is passed as written.
+func cmdCommand(shell, command string) *exec.Cmd {
+
+	cmd := exec.Command(shell)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine: shell + ` /S /C "` + command + `"`,
+	}
+
+	return cmd
+}
+
+// newGroup - Start cmd in a process group of its own, so Ctrl-C in this
+// console doesn't reach it.
+func newGroup(cmd *exec.Cmd) {
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// joinGroup - Windows can't add a process to an existing group, so cmd gets
+// a group of its own, and is left to exit when its input closes.
+func joinGroup(cmd *exec.Cmd, pid int) {
+	newGroup(cmd)
+}
+
+// signalGroup - End the process pid. Its children aren't reached, since
+// Windows has no way to signal a group.
+func signalGroup(pid int, sig syscall.Signal) error {
+
+	process, err := os.FindProcess(pid)
+
+	if err != nil {
+		return nil
+	}
+
+	if err := process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+
+	return nil
+}
+
+// processAlive - Whether pid is a live process.
+func processAlive(pid int) bool {
+
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+
+	if err != nil {
+		return false
+	}
+
+	defer syscall.CloseHandle(handle)
+
+	var code uint32
+
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+
+	return code == stillActive
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultShellWindows(t *testing.T) {
+
+	if got := (Config{}).shell(); got != "cmd" {
+		t.Errorf("default shell = %s, want cmd", got)
+	}
+
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{defaultShell, `cmd /S /C "echo "hi""`},
+		{`C:\Windows\System32\cmd.exe`, `C:\Windows\System32\cmd.exe /S /C "echo "hi""`},
+	}
+
+	for _, test := range tests {
+		cmd := shellCommand(test.shell, `echo "hi"`)
+
+		if cmd.SysProcAttr == nil {
+			t.Fatalf("shellCommand(%s) has no command line", test.shell)
+		}
+
+		// Passed as written, rather than quoted the way Go would.
+		if got := cmd.SysProcAttr.CmdLine; got != test.want {
+			t.Errorf("shellCommand(%s) runs %s, want %s", test.shell, got, test.want)
+		}
+	}
+
+	// Windows paths, which only split into a directory and name here.
+	pwsh := `C:\Program Files\PowerShell\7\pwsh.exe`
+	want := []string{pwsh, "-NoProfile", "-NonInteractive", "-Command", "echo hi"}
+
+	if got := shellCommand(pwsh, "echo hi").Args; !reflect.DeepEqual(got, want) {
+		t.Errorf("shellCommand(%s) runs %q, want %q", pwsh, got, want)
+	}
+}
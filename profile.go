@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// Set while proj is profiling itself, so cliError can finish the files
+// before exiting.
+var stopProfile func()
+
+// startProfiling - Profile proj itself, writing a CPU profile to cpuPath
+// and an execution trace to tracePath, either of which may be "". Call the
+// returned function to finish them. Read them with go tool pprof and go
+// tool trace.
+func startProfiling(cpuPath, tracePath string) func() {
+
+	var files []*os.File
+
+	create := func(path string) *os.File {
+		file, err := os.Create(path)
+
+		if err != nil {
+			cliError(fmt.Errorf("Could not create %s: %s", path, err))
+		}
+
+		files = append(files, file)
+		return file
+	}
+
+	if cpuPath != "" {
+		if err := pprof.StartCPUProfile(create(cpuPath)); err != nil {
+			cliError(fmt.Errorf("Could not start the CPU profile: %s", err))
+		}
+	}
+
+	if tracePath != "" {
+		if err := trace.Start(create(tracePath)); err != nil {
+			cliError(fmt.Errorf("Could not start the trace: %s", err))
+		}
+	}
+
+	stopProfile = func() {
+		stopProfile = nil
+
+		if cpuPath != "" {
+			pprof.StopCPUProfile()
+		}
+
+		if tracePath != "" {
+			trace.Stop()
+		}
+
+		for _, file := range files {
+			file.Close()
+		}
+	}
+
+	return stopProfile
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LogPolicy - How long detached projects' logs are kept, and how much
+// space they may take between them. Zero means no limit.
+type LogPolicy struct {
+	MaxAge  time.Duration
+	MaxSize int64
+}
+
+// logPolicy - The retention policy from config, or the zero policy.
+func (c Config) logPolicy() LogPolicy {
+
+	var policy LogPolicy
+
+	if age, err := time.ParseDuration(c.LogMaxAge); err == nil {
+		policy.MaxAge = age
+	}
+
+	if size, err := parseMemory(c.LogMaxSize); err == nil {
+		policy.MaxSize = size
+	}
+
+	return policy
+}
+
+// PruneLogs - Remove logs last written longer ago than the policy's max
+// age, then the oldest of the rest until they fit in its max size, and
+// report the space reclaimed. Logs of running projects are kept, as
+// they're still being written. With dryRun, only report what would go.
+func (proj *Proj) PruneLogs(policy LogPolicy, dryRun bool) {
+
+	if policy.MaxAge == 0 && policy.MaxSize == 0 {
+		cliError(errors.New("No retention policy: pass --max-age or --max-size, or set log_max_age or log_max_size with proj config set."))
+	}
+
+	removed, reclaimed := proj.pruneLogs(policy, dryRun)
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+
+	for _, name := range removed {
+		cliOut(verb + " " + name)
+	}
+
+	cliSuccessOut(fmt.Sprintf("%s %d log(s), reclaiming %s.", verb, len(removed), formatSize(reclaimed)))
+}
+
+// autoPruneLogs - Apply the configured retention policy, if there is one,
+// saying so only if anything was removed.
+func (proj *Proj) autoPruneLogs() {
+
+	policy := config.logPolicy()
+
+	if policy.MaxAge == 0 && policy.MaxSize == 0 {
+		return
+	}
+
+	if removed, reclaimed := proj.pruneLogs(policy, false); len(removed) > 0 {
+		cliOut(fmt.Sprintf("Pruned %d old log(s), reclaiming %s.", len(removed), formatSize(reclaimed)))
+	}
+}
+
+// pruneLogs - Apply policy to the logs directory, returning the logs
+// removed and the bytes reclaimed.
+func (proj *Proj) pruneLogs(policy LogPolicy, dryRun bool) ([]string, int64) {
+
+	dir := filepath.Join(projDir(), "logs")
+
+	files, err := ioutil.ReadDir(dir)
+
+	if os.IsNotExist(err) {
+		return nil, 0
+	}
+
+	if err != nil {
+		cliError(err)
+	}
+
+	running := map[string]bool{}
+	for _, project := range proj.ListProjects() {
+		if project.Running() {
+			running[filepath.Base(logPath(project))] = true
+		}
+	}
+
+	var logs []os.FileInfo
+	var total int64
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".log") || running[file.Name()] {
+			continue
+		}
+		logs = append(logs, file)
+		total += file.Size()
+	}
+
+	// Oldest first, so they're the first to go for space.
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].ModTime().Before(logs[j].ModTime())
+	})
+
+	var removed []string
+	var reclaimed int64
+
+	for _, log := range logs {
+		expired := policy.MaxAge > 0 && time.Since(log.ModTime()) > policy.MaxAge
+		over := policy.MaxSize > 0 && total > policy.MaxSize
+
+		if !expired && !over {
+			continue
+		}
+
+		if !dryRun {
+			if err := os.Remove(filepath.Join(dir, log.Name())); err != nil {
+				cliWarn(fmt.Sprintf("Failed to remove %s: %s", log.Name(), err))
+				continue
+			}
+		}
+
+		removed = append(removed, log.Name())
+		reclaimed += log.Size()
+		total -= log.Size()
+	}
+
+	return removed, reclaimed
+}
+
+// formatSize - A size in bytes, in the largest unit that keeps it at 1 or
+// more, e.g. 1.5M.
+func formatSize(size int64) string {
+
+	units := []string{"K", "M", "G"}
+	value := float64(size)
+
+	if value < 1024 {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	unit := ""
+	for _, next := range units {
+		if value < 1024 {
+			break
+		}
+		value /= 1024
+		unit = next
+	}
+
+	return fmt.Sprintf("%.1f%s", value, unit)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/fatih/color"
+)
+
+// Set while output is held back by --quiet-on-success: prints what was
+// held, so cliError can show what led up to a failure.
+var flushQuiet func()
+
+// holdOutput - Hold back everything proj prints, the commands and their
+// output included, until the returned function is called, which drops it.
+// If proj fails first, cliError prints it all instead.
+func holdOutput() func() {
+
+	var held bytes.Buffer
+
+	output := color.Output
+	color.Output = &held
+
+	restore := func() {
+		flushQuiet = nil
+		color.Output = output
+	}
+
+	flushQuiet = func() {
+		restore()
+		output.Write(held.Bytes())
+	}
+
+	return restore
+}
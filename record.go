@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ANSI escape sequences, stripped from recorded output.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// recorder - Writes a self-contained record of a run to a file: the
+// commands, redacted env, timestamped output and exit code. stdout and
+// stderr are written from goroutines of their own, so writes are locked.
+type recorder struct {
+	file *os.File
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+// newRecorder - Create (or truncate) the record file at path.
+func newRecorder(path string) *recorder {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		cliError(err)
+	}
+
+	file, err := os.Create(path)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	return &recorder{file: file}
+}
+
+// Header - Record what's about to run. Env values are redacted, since they
+// often hold secrets; only the names are kept.
+func (r *recorder) Header(project Project, commands []string) {
+
+	fmt.Fprintf(r.file, "# proj start %s\n", project.Name)
+	fmt.Fprintf(r.file, "# started: %s\n", time.Now().Format(time.RFC3339))
+
+	if project.Host != "" {
+		fmt.Fprintf(r.file, "# host: %s\n", project.Host)
+	}
+
+	fmt.Fprintf(r.file, "# dir: %s\n", project.Dir())
+
+	for _, command := range commands {
+		fmt.Fprintf(r.file, "# command: %s\n", command)
+	}
+
+	keys := make([]string, 0, len(project.Env))
+	for key := range project.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(r.file, "# env: %s=<redacted>\n", key)
+	}
+}
+
+// Write - Record output, one timestamped line at a time.
+func (r *recorder) Write(p []byte) (int, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.partial = append(r.partial, p...)
+
+	for {
+		i := bytes.IndexByte(r.partial, '\n')
+
+		if i < 0 {
+			break
+		}
+
+		r.writeLine(r.partial[:i])
+		r.partial = r.partial[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// writeLine - Write one line of output with its timestamp.
+func (r *recorder) writeLine(line []byte) {
+
+	clean := ansiEscape.ReplaceAll(line, nil)
+	clean = bytes.TrimRight(clean, "\r")
+
+	fmt.Fprintf(r.file, "[%s] %s\n", time.Now().Format("15:04:05.000"), clean)
+}
+
+// Flush - Write out any unterminated line, e.g. when a command exits.
+func (r *recorder) Flush() {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.flushPartial()
+}
+
+// flushPartial - Flush, with the lock held.
+func (r *recorder) flushPartial() {
+
+	if len(r.partial) > 0 {
+		r.writeLine(r.partial)
+		r.partial = nil
+	}
+}
+
+// Finish - Record the exit code and close.
+func (r *recorder) Finish(code int) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.flushPartial()
+
+	fmt.Fprintf(r.file, "# exit code: %d\n", code)
+	fmt.Fprintf(r.file, "# finished: %s\n", time.Now().Format(time.RFC3339))
+
+	if err := r.file.Close(); err != nil {
+		cliWarn("Failed to write record: " + err.Error())
+		return
+	}
+
+	cliOut("Recorded run to " + r.file.Name())
+}
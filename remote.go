@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A variable at the start of a path, like $HOME in $HOME/code.
+var leadingVar = regexp.MustCompile(`^\$(\{[A-Za-z_][A-Za-z0-9_]*\}|[A-Za-z_][A-Za-z0-9_]*)`)
+
+// remoteCommand - Build an ssh command that runs command on the project's
+// host, from its remote path and with its env.
+//
+// Only the project's own env is sent, not proj's local environment. Relative
+// path_prepend entries are resolved against the remote path, and prepended
+// to the remote PATH.
+func remoteCommand(project Project, command string) *exec.Cmd {
+
+	var script []string
+
+	if project.Path != "" {
+		script = append(script, "cd "+remotePathQuote(project.Path))
+	}
+
+	keys := make([]string, 0, len(project.Env))
+	for key := range project.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		script = append(script, "export "+key+"="+shellQuote(project.Env[key]))
+	}
+
+	if len(project.PathPrepend) > 0 {
+		dirs := make([]string, 0, len(project.PathPrepend))
+
+		for _, dir := range project.PathPrepend {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(project.Path, dir)
+			}
+			dirs = append(dirs, remotePathQuote(dir))
+		}
+
+		script = append(script, `export PATH=`+strings.Join(dirs, ":")+`:"$PATH"`)
+	}
+
+	script = append(script, command)
+
+	cmd := exec.Command("ssh", project.Host, strings.Join(script, " && "))
+
+	// ssh itself runs locally, and needs things like SSH_AUTH_SOCK.
+	cmd.Env = os.Environ()
+
+	return cmd
+}
+
+// remotePathQuote - Quote a path for the remote shell. A leading variable,
+// as paths like $HOME/code are kept, is left for the remote shell to
+// expand, in double quotes; the rest is quoted as written.
+func remotePathQuote(path string) string {
+
+	prefix := leadingVar.FindString(path)
+
+	if prefix == "" {
+		return shellQuote(path)
+	}
+
+	quoted := `"` + prefix + `"`
+
+	if rest := path[len(prefix):]; rest != "" {
+		quoted += shellQuote(rest)
+	}
+
+	return quoted
+}
+
+// hostname - The host a project runs on, without any user.
+func (project Project) hostname() string {
+
+	if project.Host == "" {
+		return "localhost"
+	}
+
+	host := project.Host
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+
+	return host
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestRemotePathQuote(t *testing.T) {
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/srv/my api", "/srv/my api"},
+		{"$HOME/code/it's", "/home/dev/code/it's"},
+		{"${HOME}/code", "/home/dev/code"},
+		{"$HOME", "/home/dev"},
+		{"/srv/$HOME", "/srv/$HOME"},
+	}
+
+	for _, test := range tests {
+		// Run through a shell, as the remote end would.
+		cmd := exec.Command("sh", "-c", "printf %s "+remotePathQuote(test.path))
+		cmd.Env = []string{"HOME=/home/dev"}
+
+		out, err := cmd.Output()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := string(out); got != test.want {
+			t.Errorf("%s came out as %s, want %s", test.path, got, test.want)
+		}
+	}
+}
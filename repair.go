@@ -0,0 +1,366 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	schemaObjects = `
+        SELECT type, name, sql FROM sqlite_master
+        WHERE name NOT LIKE 'sqlite_%' AND sql IS NOT NULL
+    `
+
+	deleteOrphanTags = `
+        DELETE FROM project_tags
+        WHERE ProjectId NOT IN (SELECT Id FROM projects)
+    `
+
+	deleteOrphanRuns = `
+        DELETE FROM project_runs
+        WHERE ProjectId NOT IN (SELECT Id FROM projects)
+    `
+
+	deleteOrphanAliases = `
+        DELETE FROM project_aliases
+        WHERE ProjectId NOT IN (SELECT Id FROM projects)
+    `
+)
+
+// schemaObject - A table or index, as sqlite_master describes it.
+type schemaObject struct {
+	Type string
+	Name string
+	SQL  string
+}
+
+// column - A table column, as PRAGMA table_info describes it.
+type column struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default sql.NullString
+}
+
+// Repair - Check the database's integrity, bring its schema in line with
+// this version of proj, remove rows that belong to no project, and vacuum
+// it. Reports everything it fixed.
+func (proj *Proj) Repair() {
+
+	release := holdInterrupts()
+	defer release()
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	if problems := integrityCheck(proj.db); len(problems) > 0 {
+		for _, problem := range problems {
+			cliWarn(problem)
+		}
+		cliError(errors.New("The database is damaged beyond what repair can fix, restore a snapshot with proj restore."))
+	}
+
+	cliOut("Integrity check passed.")
+
+	fixed := 0
+
+	var before, after int
+	proj.db.QueryRow("PRAGMA user_version").Scan(&before)
+
+	MigrateDB(proj.db)
+
+	proj.db.QueryRow("PRAGMA user_version").Scan(&after)
+
+	if after > before {
+		cliOut(fmt.Sprintf("Applied %d pending migration(s).", after-before))
+		fixed++
+	}
+
+	// A newer proj's columns would never come back, so they're only
+	// dropped from a schema this proj knows all of, and after a snapshot.
+	var beforeDrop func() error
+
+	if before > len(migrations) {
+		cliWarn(fmt.Sprintf("The database is from a newer proj (schema %d, this one knows %d), so columns this proj doesn't know are kept.", before, len(migrations)))
+	} else {
+		beforeDrop = proj.repairSnapshot()
+	}
+
+	fixed += repairSchema(proj.db, beforeDrop)
+
+	result, err := proj.db.Exec(deleteOrphanTags)
+
+	if err != nil {
+		cliError(fmt.Errorf("Failed to remove orphaned tags: %s", err))
+	}
+
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		cliOut(fmt.Sprintf("Removed %d tag(s) belonging to deleted projects.", removed))
+		fixed++
+	}
+
+	result, err = proj.db.Exec(deleteOrphanRuns)
+
+	if err != nil {
+		cliError(fmt.Errorf("Failed to remove orphaned run history: %s", err))
+	}
+
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		cliOut(fmt.Sprintf("Removed %d run(s) belonging to deleted projects.", removed))
+		fixed++
+	}
+
+	result, err = proj.db.Exec(deleteOrphanAliases)
+
+	if err != nil {
+		cliError(fmt.Errorf("Failed to remove orphaned aliases: %s", err))
+	}
+
+	if removed, _ := result.RowsAffected(); removed > 0 {
+		cliOut(fmt.Sprintf("Removed %d alias(es) of deleted projects.", removed))
+		fixed++
+	}
+
+	if _, err := proj.db.Exec("VACUUM"); err != nil {
+		cliError(fmt.Errorf("Failed to vacuum database: %s", err))
+	}
+
+	cliOut("Vacuumed database.")
+
+	if fixed == 0 {
+		cliSuccessOut("Nothing needed fixing.")
+		return
+	}
+
+	cliSuccessOut(fmt.Sprintf("Fixed %d problem(s).", fixed))
+}
+
+// repairSnapshot - A func that snapshots the database next to it, the
+// first time it's called, for repair to call before dropping anything.
+func (proj *Proj) repairSnapshot() func() error {
+
+	var taken bool
+
+	return func() error {
+		if taken {
+			return nil
+		}
+
+		file := config.dbPath() + ".before-repair-" + time.Now().Format("20060102T150405")
+
+		if _, err := proj.db.Exec("VACUUM INTO ?", file); err != nil {
+			return err
+		}
+
+		taken = true
+		cliOut("Saved a snapshot to " + file + " before dropping columns, put it back with proj restore if anything's missing.")
+		return nil
+	}
+}
+
+// integrityCheck - Whatever PRAGMA integrity_check finds wrong.
+func integrityCheck(db *sql.DB) []string {
+
+	rows, err := db.Query("PRAGMA integrity_check")
+
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	defer rows.Close()
+
+	var problems []string
+
+	for rows.Next() {
+		var result string
+		rows.Scan(&result)
+
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+
+	return problems
+}
+
+// repairSchema - Create any tables, columns and indexes the database is
+// missing, compared to a freshly migrated one, and drop columns it doesn't
+// know about, calling beforeDrop first. With no beforeDrop, they're kept.
+// Returns how many changes were made.
+func repairSchema(db *sql.DB, beforeDrop func() error) int {
+
+	// Each connection to :memory: is a new database, so keep to one.
+	expected, err := sql.Open("sqlite3", ":memory:")
+
+	if err != nil {
+		cliError(err)
+	}
+
+	defer expected.Close()
+	expected.SetMaxOpenConns(1)
+
+	CreateTable(expected)
+	MigrateDB(expected)
+
+	want := loadSchema(expected)
+	have := loadSchema(db)
+
+	fixed := 0
+
+	// Tables first, so their indexes have something to go on.
+	for _, kind := range []string{"table", "index"} {
+		for _, object := range want {
+			if object.Type != kind {
+				continue
+			}
+
+			if _, ok := findObject(have, object.Name); !ok {
+				if _, err := db.Exec(object.SQL); err != nil {
+					cliWarn(fmt.Sprintf("Failed to create %s %s: %s", kind, object.Name, err))
+					continue
+				}
+				cliOut(fmt.Sprintf("Created missing %s %s.", kind, object.Name))
+				fixed++
+				continue
+			}
+
+			if kind == "table" {
+				fixed += repairColumns(db, expected, object.Name, beforeDrop)
+			}
+		}
+	}
+
+	return fixed
+}
+
+// repairColumns - Add the columns table is missing, and drop the ones left
+// behind by other versions, as repairSchema does. Returns how many changes
+// were made.
+func repairColumns(db, expected *sql.DB, table string, beforeDrop func() error) int {
+
+	want := loadColumns(expected, table)
+	have := loadColumns(db, table)
+
+	fixed := 0
+
+	for name, col := range want {
+		if _, ok := have[name]; ok {
+			continue
+		}
+
+		definition := col.Name + " " + col.Type
+		if col.NotNull {
+			definition += " NOT NULL"
+		}
+		if col.Default.Valid {
+			definition += " DEFAULT " + col.Default.String
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, definition)); err != nil {
+			cliWarn(fmt.Sprintf("Failed to add missing column %s.%s: %s", table, name, err))
+			continue
+		}
+
+		cliOut(fmt.Sprintf("Added missing column %s.%s.", table, name))
+		fixed++
+	}
+
+	for name := range have {
+		if _, ok := want[name]; ok {
+			continue
+		}
+
+		if beforeDrop == nil {
+			cliOut(fmt.Sprintf("Kept column %s.%s, which this proj doesn't know.", table, name))
+			continue
+		}
+
+		if err := beforeDrop(); err != nil {
+			cliWarn(fmt.Sprintf("Failed to snapshot the database, so kept column %s.%s: %s", table, name, err))
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, name)); err != nil {
+			cliWarn(fmt.Sprintf("Failed to drop leftover column %s.%s: %s", table, name, err))
+			continue
+		}
+
+		cliOut(fmt.Sprintf("Dropped leftover column %s.%s.", table, name))
+		fixed++
+	}
+
+	return fixed
+}
+
+// loadSchema - The tables and indexes in db.
+func loadSchema(db *sql.DB) []schemaObject {
+
+	rows, err := db.Query(schemaObjects)
+
+	if err != nil {
+		cliError(fmt.Errorf("Failed to read schema: %s", err))
+	}
+
+	defer rows.Close()
+
+	var objects []schemaObject
+
+	for rows.Next() {
+		var object schemaObject
+
+		if err := rows.Scan(&object.Type, &object.Name, &object.SQL); err != nil {
+			cliError(fmt.Errorf("Failed to read schema: %s", err))
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects
+}
+
+// findObject - The object in objects called name.
+func findObject(objects []schemaObject, name string) (schemaObject, bool) {
+
+	for _, object := range objects {
+		if strings.EqualFold(object.Name, name) {
+			return object, true
+		}
+	}
+
+	return schemaObject{}, false
+}
+
+// loadColumns - table's columns, by name.
+func loadColumns(db *sql.DB, table string) map[string]column {
+
+	// PRAGMA doesn't accept bound parameters; table names come from the
+	// schema, not the user.
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+
+	if err != nil {
+		cliError(fmt.Errorf("Failed to read columns of %s: %s", table, err))
+	}
+
+	defer rows.Close()
+
+	columns := map[string]column{}
+
+	for rows.Next() {
+		var (
+			cid int
+			col column
+			pk  int
+		)
+
+		if err := rows.Scan(&cid, &col.Name, &col.Type, &col.NotNull, &col.Default, &pk); err != nil {
+			cliError(fmt.Errorf("Failed to read columns of %s: %s", table, err))
+		}
+
+		columns[col.Name] = col
+	}
+
+	return columns
+}
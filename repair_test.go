@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// addLeftoverColumn - Add a column this proj doesn't know to projects, and
+// set the schema version.
+func addLeftoverColumn(t *testing.T, proj *Proj, version int) {
+
+	t.Helper()
+
+	if _, err := proj.db.Exec("ALTER TABLE projects ADD COLUMN FutureField TEXT"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := proj.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepairKeepsNewerColumns(t *testing.T) {
+
+	saved := config
+	defer func() { config = saved }()
+
+	dir := t.TempDir()
+	config.DBPath = filepath.Join(dir, "projects.db")
+
+	proj := newTestProj(t)
+	addLeftoverColumn(t, proj, len(migrations)+1)
+
+	proj.Repair()
+
+	if _, ok := loadColumns(proj.db, "projects")["FutureField"]; !ok {
+		t.Error("repair dropped a column from a newer proj")
+	}
+
+	if matches, _ := filepath.Glob(config.DBPath + ".before-repair-*"); len(matches) != 0 {
+		t.Errorf("got snapshots %v, want none as nothing was dropped", matches)
+	}
+}
+
+func TestRepairSnapshotsBeforeDropping(t *testing.T) {
+
+	saved := config
+	defer func() { config = saved }()
+
+	dir := t.TempDir()
+	config.DBPath = filepath.Join(dir, "projects.db")
+
+	proj := newTestProj(t)
+	addLeftoverColumn(t, proj, len(migrations))
+
+	proj.Repair()
+
+	if _, ok := loadColumns(proj.db, "projects")["FutureField"]; ok {
+		t.Error("repair kept a leftover column")
+	}
+
+	matches, _ := filepath.Glob(config.DBPath + ".before-repair-*")
+
+	if len(matches) != 1 {
+		t.Fatalf("got snapshots %v, want one", matches)
+	}
+
+	if err := checkSnapshot(matches[0]); err != nil {
+		t.Errorf("snapshot isn't usable: %s", err)
+	}
+}
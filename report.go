@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RunReport - A machine-readable summary of a start or stop, for CI.
+type RunReport struct {
+	Project   string    `json:"project"`
+	Action    string    `json:"action"`
+	Commands  []string  `json:"commands"`
+	StartedAt time.Time `json:"started_at"`
+	// Milliseconds, from the first command starting to the last finishing.
+	Duration  int64  `json:"duration_ms"`
+	ExitCode  int    `json:"exit_code"`
+	Error     string `json:"error,omitempty"`
+	Output    string `json:"output"`
+	Truncated int64  `json:"output_truncated_bytes,omitempty"`
+
+	// Labels the start was given with --label.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// reporter - Collects the output of a run, and writes a RunReport once
+// it's finished.
+type reporter struct {
+	path   string
+	report RunReport
+
+	mu     sync.Mutex
+	output *ringBuffer
+}
+
+// newReporter - Start timing a run, to be reported to path.
+func newReporter(path, action string, project Project, commands []string) *reporter {
+
+	return &reporter{
+		path: path,
+		report: RunReport{
+			Project:   project.Name,
+			Action:    action,
+			Commands:  commands,
+			StartedAt: time.Now(),
+		},
+		output: newRingBuffer(maxCapturedOutput),
+	}
+}
+
+// Write - Capture stdout and stderr, which may arrive at once.
+func (r *reporter) Write(p []byte) (int, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.output.Write(p)
+}
+
+// Finish - Write the report, with the outcome err.
+func (r *reporter) Finish(err error) {
+
+	r.report.Duration = time.Since(r.report.StartedAt).Milliseconds()
+	r.report.ExitCode = exitCode(err)
+	r.report.Output = ansiEscape.ReplaceAllString(string(r.output.Bytes()), "")
+	r.report.Truncated = r.output.Truncated()
+
+	if err != nil {
+		r.report.Error = err.Error()
+	}
+
+	data, _ := json.MarshalIndent(r.report, "", "  ")
+
+	if dir := filepath.Dir(r.path); dir != "" {
+		os.MkdirAll(dir, 0755)
+	}
+
+	if err := ioutil.WriteFile(r.path, append(data, '\n'), 0644); err != nil {
+		cliWarn("Failed to write report: " + err.Error())
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	keyring "github.com/zalando/go-keyring"
+)
+
+// References to secrets in env values, e.g. ${keyring:github/token}.
+var keyringRef = regexp.MustCompile(`\$\{keyring:([^/}]+)/([^}]+)\}`)
+
+// withSecrets - The project, with keyring references in its env replaced
+// by the secrets they point to. Only done just before commands run, so
+// secrets never reach the database, proj.yml or proj's own output.
+func (project Project) withSecrets() (Project, error) {
+
+	var failed error
+
+	env := make(map[string]string, len(project.Env))
+
+	for key, value := range project.Env {
+		env[key] = keyringRef.ReplaceAllStringFunc(value, func(ref string) string {
+			match := keyringRef.FindStringSubmatch(ref)
+			secret, err := keyring.Get(match[1], match[2])
+
+			if err != nil && failed == nil {
+				failed = fmt.Errorf("Failed to read %s/%s from the keyring, for %s: %s", match[1], match[2], key, err)
+			}
+			return secret
+		})
+	}
+
+	project.Env = env
+
+	return project, failed
+}
+
+// parseSecretName - Split a service/key secret name.
+func parseSecretName(name string) (string, string) {
+
+	parts := strings.SplitN(name, "/", 2)
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		cliError(fmt.Errorf("Secret names look like service/key, not %q.", name))
+	}
+
+	return parts[0], parts[1]
+}
+
+// SecretSet - Store a secret in the OS keyring. Without a value, it's read
+// from stdin, to keep it out of shell history.
+func SecretSet(name, value string) {
+
+	service, key := parseSecretName(name)
+
+	if value == "" {
+		value = ask("Value for "+name, "", func(value string) error {
+			if value == "" {
+				return errors.New("A value is required.")
+			}
+			return nil
+		})
+	}
+
+	if err := keyring.Set(service, key, value); err != nil {
+		cliError(fmt.Errorf("Failed to store %s: %s", name, err))
+	}
+
+	cliSuccessOut(fmt.Sprintf("Stored %s, use it in env as ${keyring:%s}", name, name))
+}
+
+// SecretGet - Print a secret from the OS keyring.
+func SecretGet(name string) {
+
+	service, key := parseSecretName(name)
+
+	value, err := keyring.Get(service, key)
+
+	if err != nil {
+		cliError(fmt.Errorf("Failed to read %s: %s", name, err))
+	}
+
+	fmt.Println(value)
+}
+
+// SecretRemove - Delete a secret from the OS keyring.
+func SecretRemove(name string) {
+
+	service, key := parseSecretName(name)
+
+	if err := keyring.Delete(service, key); err != nil {
+		cliError(fmt.Errorf("Failed to remove %s: %s", name, err))
+	}
+
+	cliSuccessOut("Removed " + name)
+}
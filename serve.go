@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// apiProject - A project as the API returns it, with whether it's running.
+type apiProject struct {
+	Project
+	Running bool `json:"running"`
+}
+
+// apiStatus - One project's entry in GET /status.
+type apiStatus struct {
+	Name         string     `json:"name"`
+	Running      bool       `json:"running"`
+	Pid          int        `json:"pid,omitempty"`
+	TearDown     bool       `json:"tear_down"`
+	LastExitCode int        `json:"last_exit_code"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+}
+
+// apiServer - Serves the API over proj's database. Starts and stops run
+// as proj subprocesses, so one that fails exits itself, not the server.
+type apiServer struct {
+	proj  *Proj
+	token string
+	self  string
+}
+
+// Serve - Serve the HTTP API on addr until interrupted. Starting and
+// stopping need token as a bearer token; if it's empty, one is generated
+// and printed.
+func (proj *Proj) Serve(addr, token string) {
+
+	self, err := os.Executable()
+
+	if err != nil {
+		cliError(err)
+	}
+
+	if token == "" {
+		bytes := make([]byte, 16)
+
+		if _, err := rand.Read(bytes); err != nil {
+			cliError(err)
+		}
+
+		token = hex.EncodeToString(bytes)
+		cliOut("Token for starting and stopping: " + token)
+	}
+
+	server := &apiServer{proj: proj, token: token, self: self}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/projects", server.handleProjects)
+	mux.HandleFunc("/projects/", server.handleProject)
+	mux.HandleFunc("/status", server.handleStatus)
+
+	cliSuccessOut("Serving the proj API on " + addr)
+
+	cliError(http.ListenAndServe(addr, mux))
+}
+
+// handleProjects - GET /projects: every project.
+func (s *apiServer) handleProjects(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		apiError(w, http.StatusMethodNotAllowed, "Use GET.")
+		return
+	}
+
+	projects := s.proj.ListProjects()
+	listed := make([]apiProject, len(projects))
+
+	for i, project := range projects {
+		listed[i] = apiProject{project, project.Running()}
+	}
+
+	apiJSON(w, http.StatusOK, listed)
+}
+
+// handleProject - GET /projects/<name>, and POST /projects/<name>/start or
+// /stop, which stream proj's output as server-sent events.
+func (s *apiServer) handleProject(w http.ResponseWriter, r *http.Request) {
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/projects/"), "/")
+	name := parts[0]
+
+	project, err := s.proj.findProject(name)
+
+	if err == sql.ErrNoRows {
+		apiError(w, http.StatusNotFound, s.proj.unknownProject(name).Error())
+		return
+	}
+
+	if err != nil {
+		apiError(w, http.StatusInternalServerError, "Failed to load project.")
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		apiJSON(w, http.StatusOK, apiProject{project, project.Running()})
+
+	case len(parts) == 2 && (parts[1] == "start" || parts[1] == "stop") && r.Method == http.MethodPost:
+		if !s.authorized(r) {
+			apiError(w, http.StatusUnauthorized, "A valid bearer token is needed to start or stop projects.")
+			return
+		}
+
+		args := []string{"--no-color", "--no-pager", parts[1], project.Name}
+		if parts[1] == "start" && r.URL.Query().Get("detach") == "true" {
+			args = append(args, "--detach")
+		}
+
+		s.stream(w, r, args)
+
+	default:
+		apiError(w, http.StatusNotFound, "Unknown endpoint.")
+	}
+}
+
+// handleStatus - GET /status: whether each project is running, and how its
+// last run went.
+func (s *apiServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		apiError(w, http.StatusMethodNotAllowed, "Use GET.")
+		return
+	}
+
+	statuses := []apiStatus{}
+
+	for _, project := range s.proj.ListProjects() {
+		statuses = append(statuses, apiStatus{
+			Name:         project.Name,
+			Running:      project.Running(),
+			Pid:          project.Pid,
+			TearDown:     project.TearDown != "",
+			LastExitCode: project.LastExitCode,
+			LastRunAt:    project.LastRunAt,
+		})
+	}
+
+	apiJSON(w, http.StatusOK, statuses)
+}
+
+// authorized - Whether the request carries the server's bearer token.
+func (s *apiServer) authorized(r *http.Request) bool {
+
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1
+}
+
+// stream - Run proj with args, sending each line of its output as an
+// "output" event, then an "exit" event with its exit code. If the client
+// goes away, proj is interrupted, as Ctrl-C would.
+func (s *apiServer) stream(w http.ResponseWriter, r *http.Request, args []string) {
+
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		apiError(w, http.StatusInternalServerError, "Streaming isn't supported.")
+		return
+	}
+
+	read, write := io.Pipe()
+
+	cmd := exec.Command(s.self, args...)
+	cmd.Stdout = write
+	cmd.Stderr = write
+
+	if err := cmd.Start(); err != nil {
+		apiError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var err error
+	exited := make(chan struct{})
+
+	go func() {
+		err = cmd.Wait()
+		write.Close()
+		close(exited)
+	}()
+
+	go func() {
+		select {
+		case <-r.Context().Done():
+			cmd.Process.Signal(os.Interrupt)
+		case <-exited:
+		}
+	}()
+
+	scanner := bufio.NewScanner(read)
+
+	for scanner.Scan() {
+		fmt.Fprintf(w, "event: output\ndata: %s\n\n", scanner.Text())
+		flusher.Flush()
+	}
+
+	// Anything past an overlong line, so proj isn't left blocked writing.
+	io.Copy(ioutil.Discard, read)
+	<-exited
+
+	code := exitCode(err)
+
+	fmt.Fprintf(w, "event: exit\ndata: {\"exit_code\": %d}\n\n", code)
+	flusher.Flush()
+}
+
+// apiJSON - Write v as the JSON response.
+func apiJSON(w http.ResponseWriter, status int, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(v)
+}
+
+// apiError - Write an error response, as {"error": message}.
+func apiError(w http.ResponseWriter, status int, message string) {
+	apiJSON(w, status, map[string]string{"error": message})
+}
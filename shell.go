@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// shellCommand - Run command through shell, passing it the way that shell
+// expects: /C for cmd, -Command for PowerShell, and -c for anything else.
+func shellCommand(shell, command string) *exec.Cmd {
+
+	switch shellName(shell) {
+	case "cmd":
+		return cmdCommand(shell, command)
+	case "powershell", "pwsh":
+		return exec.Command(shell, "-NoProfile", "-NonInteractive", "-Command", command)
+	}
+
+	return exec.Command(shell, "-c", command)
+}
+
+// posixShell - Whether shell takes sh syntax and flags, e.g. -n to check
+// a command parses.
+func posixShell(shell string) bool {
+
+	switch shellName(shell) {
+	case "cmd", "powershell", "pwsh":
+		return false
+	}
+
+	return true
+}
+
+// shellName - The shell's program name, without its directory or .exe.
+func shellName(shell string) string {
+	return strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe")
+}
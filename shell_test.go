@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShellCommand(t *testing.T) {
+
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"sh", []string{"sh", "-c", "echo hi"}},
+		{"/bin/bash", []string{"/bin/bash", "-c", "echo hi"}},
+		{"zsh", []string{"zsh", "-c", "echo hi"}},
+		{"powershell", []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", "echo hi"}},
+		{"/usr/bin/pwsh", []string{"/usr/bin/pwsh", "-NoProfile", "-NonInteractive", "-Command", "echo hi"}},
+	}
+
+	for _, test := range tests {
+		if got := shellCommand(test.shell, "echo hi").Args; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("shellCommand(%s) runs %q, want %q", test.shell, got, test.want)
+		}
+	}
+}
+
+func TestPosixShell(t *testing.T) {
+
+	tests := []struct {
+		shell string
+		want  bool
+	}{
+		{"sh", true},
+		{"/usr/bin/fish", true},
+		{"cmd", false},
+		{"CMD.EXE", false},
+		{"powershell.exe", false},
+		{"pwsh", false},
+	}
+
+	for _, test := range tests {
+		if got := posixShell(test.shell); got != test.want {
+			t.Errorf("posixShell(%s) = %t, want %t", test.shell, got, test.want)
+		}
+	}
+}
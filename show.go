@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Show - Print a project's settings and the outcome of its last run, and
+// with git, the state of its working copy.
+func (proj *Proj) Show(name string, git bool) {
+
+	project := proj.LoadProject(name)
+
+	if git {
+		project.Git = gitInfo(project)
+	}
+
+	if *output == "json" {
+		cliJSON(project)
+		return
+	}
+
+	cliOut("Name: " + project.Name)
+	cliOut("ID: " + project.ID)
+	if project.Host != "" {
+		cliOut("Host: " + project.Host)
+	}
+
+	if len(project.Tags) > 0 {
+		cliOut("Tags: " + strings.Join(project.Tags, ", "))
+	}
+
+	if dir := project.Dir(); dir != project.Path {
+		cliOut(fmt.Sprintf("Path: %s (%s)", project.Path, dir))
+	} else {
+		cliOut("Path: " + project.Path)
+	}
+
+	if project.Git != nil {
+		cliOut("Git: " + project.Git.String())
+	} else if git {
+		cliOut("Git: not a git repository")
+	}
+	for _, command := range project.StartCommands() {
+		cliOut("Command: " + command)
+	}
+
+	if project.ContinueOnError {
+		cliOut("Continue on error: yes")
+	}
+
+	if project.TearDown != "" {
+		cliOut("Tear down: " + project.TearDown)
+	} else {
+		cliOut("Tear down: none, stop only terminates a detached start")
+	}
+
+	if len(project.PathPrepend) > 0 {
+		cliOut("PATH prepend: " + strings.Join(project.PathPrepend, ", "))
+	}
+
+	keys := make([]string, 0, len(project.Env))
+	for key := range project.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		cliOut(fmt.Sprintf("Env: %s=%s", key, project.Env[key]))
+	}
+
+	if project.EnvFile != "" {
+		cliOut("Env file: " + project.EnvFile)
+	}
+
+	if len(project.EnvProfiles) > 0 {
+		profiles := make([]string, 0, len(project.EnvProfiles))
+		for profile := range project.EnvProfiles {
+			profiles = append(profiles, profile)
+		}
+		sort.Strings(profiles)
+
+		cliOut("Env profiles: " + strings.Join(profiles, ", "))
+	}
+
+	if project.CleanEnv {
+		cliOut("Clean env: yes")
+	}
+
+	if project.Limits != nil && project.Limits.Memory != "" {
+		cliOut("Memory limit: " + project.Limits.Memory)
+	}
+
+	if project.Limits != nil && project.Limits.CPU != "" {
+		cliOut("CPU limit: " + project.Limits.CPU)
+	}
+
+	if project.PidFile != "" {
+		cliOut("Pid file: " + project.PidFile)
+	}
+
+	if project.Category != "" {
+		cliOut("Category: " + project.Category)
+	}
+
+	for _, rule := range project.ColorRules {
+		cliOut(fmt.Sprintf("Color rule: %s in %s", rule.Match, rule.Color))
+	}
+
+	if project.NetworkCheck != "" {
+		cliOut("Network check: " + project.NetworkCheck)
+	}
+
+	if len(project.DependsOn) > 0 {
+		cliOut("Depends on: " + strings.Join(project.DependsOn, ", "))
+	}
+
+	if len(project.WaitFor) > 0 {
+		cliOut("Wait for: " + strings.Join(project.WaitFor, ", "))
+	}
+
+	if project.StoppedCheck != "" {
+		cliOut("Stopped check: " + project.StoppedCheck)
+	}
+
+	if project.StoppedPort != 0 {
+		cliOut(fmt.Sprintf("Stopped port: %d", project.StoppedPort))
+	}
+
+	names := make([]string, 0, len(project.Scripts))
+	for name := range project.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cliOut(fmt.Sprintf("Script: %s: %s", name, project.Scripts[name]))
+	}
+
+	if project.Notes != "" {
+		cliOut("Notes: " + project.Notes)
+	}
+
+	if project.Running() {
+		cliOut(fmt.Sprintf("Running: pid %d, logging to %s", project.Pid, logPath(project)))
+	}
+
+	cliOut("Last run: " + lastRun(project))
+}
+
+// Status - Print the outcome of every project's last run.
+func (proj *Proj) Status() {
+
+	projects := proj.ListProjects()
+
+	if *output == "json" {
+		cliJSON(projects)
+		return
+	}
+
+	for _, project := range projects {
+		line := fmt.Sprintf("%s %s: %s", cursor, project.Name, lastRun(project))
+
+		if project.Running() {
+			line = fmt.Sprintf("%s %s: running (pid %d), last run %s", cursor, project.Name, project.Pid, lastRun(project))
+		}
+
+		if project.TearDown == "" {
+			line += ", no tear down"
+		}
+
+		switch {
+		case project.LastRunAt == nil:
+			fmt.Println(line)
+		case project.LastExitCode == 0:
+			color.Green(line)
+		default:
+			color.Red(line)
+		}
+	}
+}
+
+// lastRun - Describe a project's last run, e.g. "failed (exit 1), 2h ago".
+func lastRun(project Project) string {
+
+	if project.LastRunAt == nil {
+		return "never"
+	}
+
+	result := "succeeded"
+	if project.LastExitCode != 0 {
+		result = fmt.Sprintf("failed (exit %d)", project.LastExitCode)
+	}
+
+	return result + ", " + ago(*project.LastRunAt)
+}
+
+// ago - Roughly how long ago t was.
+func ago(t time.Time) string {
+
+	d := time.Since(t)
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// parseSignal - Look up a signal by name, e.g. SIGHUP, HUP or hup.
+func parseSignal(name string) (syscall.Signal, error) {
+
+	key := strings.TrimPrefix(strings.ToUpper(name), "SIG")
+
+	if sig, ok := signals[key]; ok {
+		return sig, nil
+	}
+
+	var names []string
+	for name := range signals {
+		names = append(names, "SIG"+name)
+	}
+	sort.Strings(names)
+
+	return 0, errors.New("Unknown signal " + name + ", expected one of " + strings.Join(names, ", ") + ".")
+}
+
+// SignalProject - Send a signal to a detached project's process group,
+// instead of running its tear down.
+func (proj *Proj) SignalProject(name string, sig syscall.Signal) {
+
+	project := proj.LoadProject(name)
+
+	if !project.Running() {
+		cliWarn(project.Name + " has no running detached process to signal.")
+		return
+	}
+
+	if err := signalGroup(project.Pid, sig); err != nil {
+		cliError(fmt.Errorf("Failed to signal %d: %s", project.Pid, err))
+	}
+
+	cliSuccessOut(fmt.Sprintf("Sent %s to process group %d", sigName(sig), project.Pid))
+}
+
+// sigName - The SIG-prefixed name of a signal.
+func sigName(sig syscall.Signal) string {
+
+	for name, s := range signals {
+		if s == sig {
+			return "SIG" + name
+		}
+	}
+
+	return sig.String()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Snapshot - Write a consistent copy of the whole database to file.
+func (proj *Proj) Snapshot(file string) {
+
+	if _, err := os.Stat(file); err == nil {
+		cliError(errors.New(file + " already exists."))
+	}
+
+	// VACUUM INTO takes a transactionally consistent copy of a live database.
+	if _, err := proj.db.Exec("VACUUM INTO ?", file); err != nil {
+		cliError(errors.New("Failed to snapshot database: " + err.Error()))
+	}
+
+	cliSuccessOut("Saved snapshot to " + file)
+}
+
+// Restore - Replace the active database with a snapshot.
+func (proj *Proj) Restore(file string, yes bool) {
+
+	if err := checkSnapshot(file); err != nil {
+		cliError(errors.New(file + " isn't a proj snapshot: " + err.Error()))
+	}
+
+	target := config.dbPath()
+
+	if !yes && !confirm("Replace the database at "+target+" with "+file+"?") {
+		cliOut("Restore cancelled.")
+		return
+	}
+
+	// The active database must be closed before it's replaced.
+	proj.db.Close()
+
+	if err := copyFile(file, target); err != nil {
+		cliError(err)
+	}
+
+	cliSuccessOut("Restored database from " + file)
+}
+
+// checkSnapshot - Make sure file is a SQLite database holding projects.
+func checkSnapshot(file string) error {
+
+	db, err := sql.Open("sqlite3", "file:"+file+"?mode=ro")
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	var count int
+
+	return db.QueryRow("SELECT COUNT(*) FROM projects").Scan(&count)
+}
+
+// copyFile - Copy src over dst, via a temporary file so dst is never left half written.
+func copyFile(src, dst string) error {
+
+	in, err := os.Open(src)
+
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmp := dst + ".tmp"
+
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+// MoveDB - Move the database to path, and point the config at it. The old
+// file is kept unless removeOld is set.
+func (proj *Proj) MoveDB(path string, force, removeOld bool) {
+
+	path, old := proj.moveDB(path, force)
+
+	cliSuccessOut("Moved database to " + path)
+
+	if !removeOld {
+		cliOut("The old database is still at " + old + ", pass --remove-old to delete it.")
+		return
+	}
+
+	removeOldDB(old)
+}
+
+// moveDB - Copy the database to path, and point the config at it, saving
+// the config file. Returns the new path, made absolute, and the old one.
+func (proj *Proj) moveDB(path string, force bool) (string, string) {
+
+	path, err := filepath.Abs(path)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	old := config.dbPath()
+
+	if path == old {
+		cliError(errors.New("The database is already at " + path + "."))
+	}
+
+	if _, err := os.Stat(path); err == nil && !force {
+		cliError(errors.New(path + " already exists, pass --force to overwrite it."))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		cliError(err)
+	}
+
+	// Copied next to the target, so the final rename can't be half done.
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+
+	if _, err := proj.db.Exec("VACUUM INTO ?", tmp); err != nil {
+		cliError(errors.New("Failed to copy database: " + err.Error()))
+	}
+
+	proj.db.Close()
+
+	if err := checkSnapshot(tmp); err != nil {
+		os.Remove(tmp)
+		cliError(errors.New("The copied database didn't open: " + err.Error()))
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		cliError(err)
+	}
+
+	config.DBPath = path
+	SaveConfig(config)
+
+	return path, old
+}
+
+// removeOldDB - Delete the database at old, once it's been moved.
+func removeOldDB(old string) {
+
+	if err := os.Remove(old); err != nil {
+		cliWarn("Failed to remove the old database: " + err.Error())
+		return
+	}
+
+	cliOut("Removed " + old)
+}
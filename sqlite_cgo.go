@@ -0,0 +1,36 @@
+//go:build cgo
+
+package main
+
+import (
+	"errors"
+	"strings"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+// transientDBError - Whether err is worth retrying.
+func transientDBError(err error) bool {
+
+	var sqliteErr sqlite3.Error
+
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	switch sqliteErr.Code {
+	case sqlite3.ErrBusy, sqlite3.ErrLocked, sqlite3.ErrIoErr, sqlite3.ErrCantOpen, sqlite3.ErrProtocol:
+		return true
+	}
+
+	return false
+}
+
+// duplicateName - Whether err is a project name clashing with another's.
+func duplicateName(err error) bool {
+
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique &&
+		strings.Contains(sqliteErr.Error(), "projects.Name")
+}
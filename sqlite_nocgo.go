@@ -0,0 +1,17 @@
+//go:build !cgo
+
+package main
+
+// Without cgo, go-sqlite3 builds but can't open a database, so there are
+// no SQLite errors to tell apart. These let the rest still compile, e.g.
+// to vet other platforms.
+
+// transientDBError - Whether err is worth retrying.
+func transientDBError(err error) bool {
+	return false
+}
+
+// duplicateName - Whether err is a project name clashing with another's.
+func duplicateName(err error) bool {
+	return false
+}
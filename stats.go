@@ -0,0 +1,167 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Run history SQL statements
+var (
+	addRun = `
+        INSERT INTO project_runs(ProjectId, StartedAt, DurationMs, ExitCode, Labels)
+        VALUES(?, ?, ?, ?, ?)
+    `
+
+	clearRuns = `
+        DELETE FROM project_runs
+        WHERE ProjectId = ?
+    `
+
+	findRuns = `
+        SELECT ProjectId, StartedAt, DurationMs, ExitCode FROM project_runs
+    `
+)
+
+// ProjectStats - How often a project has been started, and how it went.
+type ProjectStats struct {
+	Name          string     `json:"name"`
+	Runs          int        `json:"runs"`
+	Failures      int        `json:"failures"`
+	SuccessRate   float64    `json:"success_rate"`
+	AvgDurationMs int64      `json:"avg_duration_ms,omitempty"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+
+	// Runs that were timed, for the average.
+	timed int
+}
+
+// Stats - Print run counts, success rates, average durations and when each
+// project was last started, busiest first. With since, only runs started
+// in that long count.
+func (proj *Proj) Stats(since time.Duration, sortBy string) {
+
+	stats := map[string]*ProjectStats{}
+
+	for _, project := range proj.ListProjects() {
+		stats[project.ID] = &ProjectStats{Name: project.Name}
+	}
+
+	rows, err := proj.db.Query(findRuns)
+
+	if err != nil {
+		cliError(errors.New("Failed to load run history."))
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var started time.Time
+		var duration sql.NullInt64
+		var code int
+
+		if err := rows.Scan(&id, &started, &duration, &code); err != nil {
+			cliError(errors.New("Failed to load run history."))
+		}
+
+		stat, ok := stats[id]
+
+		if !ok || (since > 0 && time.Since(started) > since) {
+			continue
+		}
+
+		stat.Runs++
+
+		if code != 0 {
+			stat.Failures++
+		}
+
+		if duration.Valid {
+			stat.AvgDurationMs += duration.Int64
+			stat.timed++
+		}
+
+		if stat.LastRunAt == nil || started.After(*stat.LastRunAt) {
+			started := started
+			stat.LastRunAt = &started
+		}
+	}
+
+	results := make([]ProjectStats, 0, len(stats))
+
+	for _, stat := range stats {
+		if stat.Runs > 0 {
+			stat.SuccessRate = float64(stat.Runs-stat.Failures) / float64(stat.Runs)
+		}
+
+		if stat.timed > 0 {
+			stat.AvgDurationMs /= int64(stat.timed)
+		}
+
+		results = append(results, *stat)
+	}
+
+	sortStats(results, sortBy)
+
+	if *output == "json" {
+		cliJSON(results)
+		return
+	}
+
+	w := newTable()
+
+	fmt.Fprintln(w, "NAME\tRUNS\tSUCCESS\tAVG DURATION\tLAST RUN")
+
+	for _, stat := range results {
+		success, avg, last := "-", "-", "never"
+
+		if stat.Runs > 0 {
+			success = fmt.Sprintf("%.0f%%", stat.SuccessRate*100)
+		}
+
+		if stat.timed > 0 {
+			avg = (time.Duration(stat.AvgDurationMs) * time.Millisecond).String()
+		}
+
+		if stat.LastRunAt != nil {
+			last = ago(*stat.LastRunAt)
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", stat.Name, stat.Runs, success, avg, last)
+	}
+
+	w.Flush()
+}
+
+// sortStats - Order by runs (most first), failures (most first), or last
+// run (most recent first), falling back to the name.
+func sortStats(stats []ProjectStats, sortBy string) {
+
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+
+		switch {
+		case sortBy == "failures" && a.Failures != b.Failures:
+			return a.Failures > b.Failures
+		case sortBy == "last" && !sameTime(a.LastRunAt, b.LastRunAt):
+			return b.LastRunAt == nil || (a.LastRunAt != nil && a.LastRunAt.After(*b.LastRunAt))
+		case sortBy == "runs" && a.Runs != b.Runs:
+			return a.Runs > b.Runs
+		}
+
+		return a.Name < b.Name
+	})
+}
+
+// sameTime - Whether two optional times are equal.
+func sameTime(a, b *time.Time) bool {
+
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// How many names to suggest for an unknown one, at most.
+const maxSuggestions = 3
+
+// unknownProject - The error for a name that's neither a project nor an
+// alias, suggesting close ones in case it's a typo.
+func (proj *Proj) unknownProject(name string) error {
+
+	message := "Unknown project: " + name + "."
+
+	if names := proj.suggestNames(name); len(names) > 0 {
+		message += " Did you mean: " + strings.Join(names, ", ") + "?"
+	}
+
+	return newProjectError(ErrProjectNotFound, "%s", message)
+}
+
+// suggestNames - Up to three project names or aliases close to name, the
+// closest first, for a "Did you mean" hint. Close means within two edits,
+// or a third of name's length for longer names.
+func (proj *Proj) suggestNames(name string) []string {
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	limit := len([]rune(name)) / 3
+	if limit < 2 {
+		limit = 2
+	}
+
+	var candidates []candidate
+
+	for _, known := range proj.completionRows(completeProjects) {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(known))
+
+		if distance <= limit {
+			candidates = append(candidates, candidate{known, distance})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var names []string
+
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		names = append(names, candidates[i].name)
+	}
+
+	return names
+}
+
+// editDistance - The Levenshtein distance between a and b: how many
+// single character insertions, deletions or substitutions turn one into
+// the other.
+func editDistance(a, b string) int {
+
+	from, to := []rune(a), []rune(b)
+
+	previous := make([]int, len(to)+1)
+	current := make([]int, len(to)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(from); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(to); j++ {
+			cost := 1
+			if from[i-1] == to[j-1] {
+				cost = 0
+			}
+
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(to)]
+}
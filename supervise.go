@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Restarts back off, doubling each time, up to this.
+const maxRestartBackoff = time.Minute
+
+// RestartPolicy - When and how often start --on-exit=restart brings a
+// project back up.
+type RestartPolicy struct {
+	// Most restarts before giving up, and the wait before the first.
+	Max     int
+	Backoff time.Duration
+
+	// Restart after a clean exit too, not only after a crash.
+	Always bool
+}
+
+// again - Whether to restart after a run ending with err, having already
+// restarted restarts times.
+func (policy RestartPolicy) again(err error, restarts int) bool {
+	return (err != nil || policy.Always) && restarts < policy.Max && atomic.LoadInt32(&interrupted) == 0
+}
+
+// delay - How long to wait before the given restart, counting from 1.
+func (policy RestartPolicy) delay(restart int) time.Duration {
+
+	delay := policy.Backoff
+
+	for i := 1; i < restart && delay < maxRestartBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRestartBackoff {
+		delay = maxRestartBackoff
+	}
+
+	return delay
+}
+
+// runSupervised - Run commands as runCommands does, then restart them as
+// policy says, logging each restart. Returns how the last run went.
+func (proj *Proj) runSupervised(project Project, commands []string, policy RestartPolicy, sinks ...io.Writer) error {
+
+	err := proj.runCommands(project, commands, sinks...)
+
+	for restarts := 0; policy.again(err, restarts); restarts++ {
+		delay := policy.delay(restarts + 1)
+
+		cliWarn(fmt.Sprintf("%s exited (%s), restarting (%d of %d) in %s", project.Name, exitReason(err), restarts+1, policy.Max, delay))
+		time.Sleep(delay)
+
+		if atomic.LoadInt32(&interrupted) != 0 {
+			break
+		}
+
+		err = proj.runCommands(project, commands, sinks...)
+	}
+
+	return err
+}
+
+// exitReason - How a run ended, for restart messages.
+func exitReason(err error) string {
+
+	if err == nil {
+		return "exit status 0"
+	}
+
+	return err.Error()
+}
+
+// supervisedCommand - One shell command that runs commands, restarting them
+// as policy says, for a detached start to run in place of them. Its
+// restarts are logged along with the output, and stop ends it as it would
+// the commands, as it's in their process group.
+func supervisedCommand(project Project, commands []string, policy RestartPolicy) (string, error) {
+
+	if project.Mode == "exec" {
+		return "", errors.New("--on-exit=restart in the background needs the shell, so isn't supported in exec mode.")
+	}
+
+	if project.Host == "" && !posixShell(config.shell()) {
+		return "", errors.New("--on-exit=restart in the background needs an sh-like shell, such as sh or bash.")
+	}
+
+	separator := " && "
+	if project.ContinueOnError {
+		separator = "; "
+	}
+
+	// sleep only takes whole seconds everywhere.
+	backoff := int((policy.Backoff + time.Second - 1) / time.Second)
+	if backoff < 1 {
+		backoff = 1
+	}
+	limit := int(maxRestartBackoff / time.Second)
+
+	// The commands go on their own lines, so a trailing comment can't
+	// swallow the closing parenthesis.
+	loop := []string{
+		"code=$?",
+		fmt.Sprintf("[ $code -eq 0 ] && [ %t = false ] && exit 0", policy.Always),
+		fmt.Sprintf(`if [ $restarts -ge %d ]; then echo "%s Giving up after %d restarts, exit code $code"; exit $code; fi`, policy.Max, cursor, policy.Max),
+		"restarts=$((restarts + 1))",
+		fmt.Sprintf(`echo "%s Exited with code $code, restarting ($restarts of %d) in ${delay}s"`, cursor, policy.Max),
+		"sleep $delay",
+		fmt.Sprintf("delay=$((delay * 2)); [ $delay -gt %d ] && delay=%d", limit, limit),
+		"done",
+	}
+
+	script := fmt.Sprintf("restarts=0 delay=%d; while :; do (\n%s\n); %s", backoff, strings.Join(commands, separator), strings.Join(loop, "; "))
+
+	return script, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tag SQL statements
+var (
+	clearTags = `
+        DELETE FROM project_tags
+        WHERE ProjectId = ?
+    `
+
+	addTag = `
+        INSERT OR IGNORE INTO project_tags(ProjectId, Tag)
+        VALUES(?, ?)
+    `
+
+	removeTag = `
+        DELETE FROM project_tags
+        WHERE ProjectId = ? AND Tag = ?
+    `
+
+	// Projects that already have the new tag keep it, and lose the old one
+	// below, rather than clashing on the primary key.
+	renameTag = `
+        UPDATE OR IGNORE project_tags
+        SET Tag = ?
+        WHERE Tag = ?
+    `
+
+	dropTag = `
+        DELETE FROM project_tags
+        WHERE Tag = ?
+    `
+
+	findTags = `
+        SELECT ProjectId, Tag FROM project_tags
+        ORDER BY Tag
+    `
+)
+
+// normalizeTag - Tags are compared trimmed and lower case.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// saveTags - Replace a project's tags, as part of a write transaction.
+func saveTags(tx *sql.Tx, id string, tags []string) error {
+
+	if _, err := tx.Exec(clearTags, id); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if tag = normalizeTag(tag); tag == "" {
+			continue
+		}
+
+		if _, err := tx.Exec(addTag, id, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTags - Fill in the tags of each project.
+func (proj *Proj) loadTags(projects []Project) {
+
+	rows, err := proj.db.Query(findTags)
+
+	if err != nil {
+		cliError(errors.New("Failed to load tags."))
+	}
+
+	defer rows.Close()
+
+	tags := map[string][]string{}
+
+	for rows.Next() {
+		var id, tag string
+
+		if err := rows.Scan(&id, &tag); err != nil {
+			cliError(errors.New("Failed to load tags."))
+		}
+
+		tags[id] = append(tags[id], tag)
+	}
+
+	for i := range projects {
+		projects[i].Tags = tags[projects[i].ID]
+	}
+}
+
+// withTag - The projects that have tag.
+func withTag(projects []Project, tag string) []Project {
+
+	tag = normalizeTag(tag)
+	tagged := []Project{}
+
+	for _, project := range projects {
+		if contains(project.Tags, tag) {
+			tagged = append(tagged, project)
+		}
+	}
+
+	return tagged
+}
+
+// TagAdd - Tag a project.
+func (proj *Proj) TagAdd(name, tag string) {
+
+	project := proj.LoadProject(name)
+
+	if tag = normalizeTag(tag); tag == "" {
+		cliError(errors.New("Tags can't be empty."))
+	}
+
+	proj.execTags(addTag, project.ID, tag)
+
+	cliSuccessOut(fmt.Sprintf("Tagged %s with %s", project.Name, tag))
+}
+
+// TagRemove - Remove a tag from a project.
+func (proj *Proj) TagRemove(name, tag string) {
+
+	project := proj.LoadProject(name)
+	tag = normalizeTag(tag)
+
+	if !contains(project.Tags, tag) {
+		cliError(fmt.Errorf("%s isn't tagged %s.", project.Name, tag))
+	}
+
+	proj.execTags(removeTag, project.ID, tag)
+
+	cliSuccessOut(fmt.Sprintf("Removed %s from %s", tag, project.Name))
+}
+
+// TagRename - Rename a tag on every project that has it.
+func (proj *Proj) TagRename(oldTag, newTag string) {
+
+	given := newTag
+	oldTag, newTag = normalizeTag(oldTag), normalizeTag(newTag)
+
+	if newTag == "" {
+		cliError(errors.New("Tags can't be empty."))
+	}
+
+	// Renaming a tag to itself would drop it from every project below.
+	if newTag == oldTag {
+		cliOut(fmt.Sprintf("%s is the same tag as %s, nothing to rename.", given, oldTag))
+		return
+	}
+
+	var names []string
+
+	for _, project := range withTag(proj.ListProjects(), oldTag) {
+		names = append(names, project.Name)
+	}
+
+	if len(names) == 0 {
+		cliError(errors.New("No projects are tagged " + oldTag + "."))
+	}
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	err := proj.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(renameTag, newTag, oldTag); err != nil {
+			return err
+		}
+		_, err := tx.Exec(dropTag, oldTag)
+		return err
+	})
+
+	if err != nil {
+		cliError(errors.New("Failed to rename tag."))
+	}
+
+	sort.Strings(names)
+	cliSuccessOut(fmt.Sprintf("Renamed %s to %s on %s", oldTag, newTag, strings.Join(names, ", ")))
+}
+
+// execTags - Run a single tag statement.
+func (proj *Proj) execTags(query string, args ...interface{}) {
+
+	proj.mu.Lock()
+	defer proj.mu.Unlock()
+
+	if _, err := proj.db.Exec(query, args...); err != nil {
+		cliError(errors.New("Failed to update tags."))
+	}
+}
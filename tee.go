@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+)
+
+// tee - Shows a run's output live, stdout and stderr alike, as it appends
+// it to a file, like tee -a.
+type tee struct {
+	file    *os.File
+	display io.Writer
+	out     io.Writer
+}
+
+// newTee - Open the file at path for appending, creating it and its
+// directory if need be. The project's color rules apply to the display.
+func newTee(path string, project Project) *tee {
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		cliError(err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	display := jsonLogWriter(highlightWriter(streamWriter(color.Output), project))
+
+	return &tee{file: file, display: display, out: io.MultiWriter(display, file)}
+}
+
+// Write - Show p, and append it to the file.
+func (t *tee) Write(p []byte) (int, error) {
+	return t.out.Write(p)
+}
+
+// Flush - Show any partial line held back for display.
+func (t *tee) Flush() {
+	flush(t.display)
+}
+
+// Close - Close the file.
+func (t *tee) Close() {
+
+	if err := t.file.Close(); err != nil {
+		cliWarn("Failed to save the tee file: " + err.Error())
+	}
+}
+
+// showsOutput - Whether one of sinks already shows the output as it runs,
+// so it needn't be printed again afterwards.
+func showsOutput(sinks []io.Writer) bool {
+
+	for _, sink := range sinks {
+		if _, ok := sink.(*tee); ok {
+			return true
+		}
+	}
+
+	return false
+}
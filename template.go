@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// CommandVars - What commands can refer to as templates, e.g. {{.Name}}.
+type CommandVars struct {
+	ID   string
+	Name string
+	Path string
+	Host string
+	Tags []string
+
+	// The environment the commands get, so {{.Env.HOME}} works too.
+	Env map[string]string
+}
+
+// Extra template functions, e.g. {{quote .Path}} or {{date "2006-01-02"}}.
+var commandFuncs = template.FuncMap{
+	"quote": shellQuote,
+	"date":  runStarted.Format,
+}
+
+// expandCommand - Fill in a command's template variables from the project.
+// Time variables like ${date} are filled in first. Commands without {{ are
+// otherwise left alone. Values go in as they are, so quote any that may
+// hold spaces.
+func expandCommand(project Project, command string) (string, error) {
+
+	command = expandTimes(command)
+
+	if !strings.Contains(command, "{{") {
+		return command, nil
+	}
+
+	tmpl, err := template.New("command").Funcs(commandFuncs).Option("missingkey=error").Parse(command)
+
+	if err == nil {
+		var expanded strings.Builder
+
+		if err = tmpl.Execute(&expanded, commandVars(project)); err == nil {
+			return expanded.String(), nil
+		}
+	}
+
+	return "", fmt.Errorf("Failed to expand %q: %s (write {{\"{{\"}} for a literal {{)", command, err)
+}
+
+// commandVars - The template variables for a project's commands.
+func commandVars(project Project) CommandVars {
+
+	env := map[string]string{}
+
+	for _, kv := range project.withTimes().ResolvedEnv() {
+		parts := strings.SplitN(kv, "=", 2)
+		env[parts[0]] = parts[1]
+	}
+
+	return CommandVars{
+		ID:   project.ID,
+		Name: project.Name,
+		Path: project.Dir(),
+		Host: project.Host,
+		Tags: project.Tags,
+		Env:  env,
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// throttle - An io.Writer that passes on at most limit lines a second,
+// dropping the rest and noting how many were dropped.
+type throttle struct {
+	out   io.Writer
+	limit int
+
+	mu         sync.Mutex
+	window     time.Time
+	lines      int
+	suppressed int
+	dropping   bool
+	midLine    bool
+}
+
+// streamWriter - w, throttled to --max-lines-per-sec if it's set.
+func streamWriter(w io.Writer) io.Writer {
+
+	if *maxLinesPerSec <= 0 {
+		return w
+	}
+
+	return &throttle{out: w, limit: *maxLinesPerSec}
+}
+
+// Write - Pass on the lines in p that fit in the current second.
+func (t *throttle) Write(p []byte) (int, error) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := len(p)
+
+	for len(p) > 0 {
+		if !t.midLine {
+			t.startLine()
+		}
+
+		chunk := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			chunk = p[:i+1]
+		}
+
+		if !t.dropping {
+			if _, err := t.out.Write(chunk); err != nil {
+				return n, err
+			}
+		}
+
+		t.midLine = chunk[len(chunk)-1] != '\n'
+		p = p[len(chunk):]
+	}
+
+	return n, nil
+}
+
+// startLine - Decide whether the line about to be written is let through.
+func (t *throttle) startLine() {
+
+	if now := time.Now(); now.Sub(t.window) >= time.Second {
+		t.report()
+		t.window = now
+		t.lines = 0
+	}
+
+	t.lines++
+	t.dropping = t.lines > t.limit
+
+	if t.dropping {
+		t.suppressed++
+	}
+}
+
+// report - Note any lines dropped since the last note.
+func (t *throttle) report() {
+
+	if t.suppressed > 0 {
+		fmt.Fprintf(t.out, "... %d lines suppressed\n", t.suppressed)
+		t.suppressed = 0
+	}
+}
+
+// Flush - Note dropped lines now, rather than waiting for more output.
+func (t *throttle) Flush() {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.midLine {
+		t.report()
+	}
+}
+
+// flush - Flush w, if it buffers anything.
+func flush(w io.Writer) {
+
+	if f, ok := w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+}
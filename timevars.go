@@ -0,0 +1,56 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// When proj started, so every time variable in one start agrees, e.g. a
+// backup command and the env naming the file it writes.
+var runStarted = time.Now()
+
+// References to the time in commands, env values and the project path,
+// e.g. ${date}, ${date:2006-01-02_15-04} or ${timestamp}.
+var timeRef = regexp.MustCompile(`\$\{(date(:[^}]*)?|timestamp)\}`)
+
+// timeVar - The value of the time variable called name, as it's written
+// inside ${...}, and whether it is one. ${date} is the date alone; with a
+// Go layout after a colon, the time is written in that layout instead.
+func timeVar(name string) (string, bool) {
+
+	switch {
+	case name == "timestamp":
+		return strconv.FormatInt(runStarted.Unix(), 10), true
+	case name == "date":
+		return runStarted.Format("2006-01-02"), true
+	case strings.HasPrefix(name, "date:"):
+		return runStarted.Format(strings.TrimPrefix(name, "date:")), true
+	}
+
+	return "", false
+}
+
+// expandTimes - s with its time variables filled in.
+func expandTimes(s string) string {
+
+	return timeRef.ReplaceAllStringFunc(s, func(ref string) string {
+		value, _ := timeVar(ref[2 : len(ref)-1])
+		return value
+	})
+}
+
+// withTimes - The project, with time variables in its env filled in.
+func (project Project) withTimes() Project {
+
+	env := make(map[string]string, len(project.Env))
+
+	for key, value := range project.Env {
+		env[key] = expandTimes(value)
+	}
+
+	project.Env = env
+
+	return project
+}
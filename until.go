@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// startUntil - Start the project detached, printing its output until a line
+// matches ready. The project is then left running in the background. It's a
+// failure if the project exits first.
+func (proj *Proj) startUntil(project Project, commands []string, ready *regexp.Regexp) error {
+
+	d, err := proj.spawnDetached(project, commands)
+
+	if err != nil {
+		return err
+	}
+
+	// Waited on here, rather than released, so an early exit is noticed.
+	exited := make(chan error, 1)
+	go func() { exited <- d.Wait() }()
+
+	log, err := os.Open(logPath(project))
+
+	if err != nil {
+		return err
+	}
+
+	defer log.Close()
+
+	if _, err := log.Seek(d.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	cliOut(fmt.Sprintf("Waiting for a line matching %s", ready))
+
+	reader := bufio.NewReader(log)
+	line := ""
+
+	out := jsonLogWriter(highlightWriter(streamWriter(os.Stdout), project))
+	defer flush(out)
+
+	for {
+		chunk, err := reader.ReadString('\n')
+		line += chunk
+
+		if err == nil {
+			_, text := splitLogLine(line)
+			io.WriteString(out, text)
+
+			if ready.MatchString(strings.TrimRight(text, "\r\n")) {
+				cliSuccessOut(fmt.Sprintf("%s is ready, running in the background (pid %d), logging to %s", project.Name, d.cmd.Process.Pid, logPath(project)))
+				return nil
+			}
+
+			line = ""
+			continue
+		}
+
+		if err != io.EOF {
+			return err
+		}
+
+		flush(out)
+
+		// Only a partial line so far, wait for more.
+		select {
+		case err := <-exited:
+			rest, _ := ioutil.ReadAll(reader)
+
+			for _, line := range strings.SplitAfter(line+string(rest), "\n") {
+				_, text := splitLogLine(line)
+				io.WriteString(out, text)
+			}
+
+			proj.SetPid(project, 0)
+
+			if err == nil {
+				err = errors.New("exit status 0")
+			}
+			return fmt.Errorf("%s exited before printing a line matching %s: %w", project.Name, ready, err)
+
+		case <-time.After(followInterval):
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Fields init never overwrites on an existing project: its identity, and
+// state proj keeps for itself.
+var preservedFields = map[string]bool{
+	"ID":             true,
+	"CreatedAt":      true,
+	"UpdatedAt":      true,
+	"LastExitCode":   true,
+	"LastRunAt":      true,
+	"LastUsedAt":     true,
+	"Pid":            true,
+	"WrittenPidFile": true,
+	"Git":            true,
+}
+
+// ReinitProject - Update an existing project with every field given to init,
+// keeping its ID and CreatedAt, so init scripts can be run again.
+func (proj *Proj) ReinitProject(given Project) {
+
+	release := holdInterrupts()
+	defer release()
+
+	project := proj.LoadProject(given.Name)
+
+	if given.ID != "" && given.ID != project.ID {
+		cliError(fmt.Errorf("%s already exists with the ID %s, not %s.", project.Name, project.ID, given.ID))
+	}
+
+	current := reflect.ValueOf(&project).Elem()
+	update := reflect.ValueOf(given)
+
+	for i := 0; i < update.NumField(); i++ {
+		field := update.Type().Field(i)
+
+		if preservedFields[field.Name] || update.Field(i).IsZero() {
+			continue
+		}
+
+		current.Field(i).Set(update.Field(i))
+	}
+
+	// The commands are given as one list, so replace them together.
+	if given.Command != "" {
+		project.Commands = given.Commands
+	}
+
+	proj.UpdateProject(project)
+
+	if project.Host == "" {
+		proj.CreateProjectFile(project)
+	}
+
+	cliOut("Updated project: " + project.Name)
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	uuid "github.com/satori/go.uuid"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Project file fields holding commands, whose stray whitespace --fix trims.
+var commandFields = []string{"command", "tear_down", "verify_command", "stopped_check"}
+
+// projectFileFix - A problem --fix can correct, and how it does.
+type projectFileFix struct {
+	problem string
+	change  string
+}
+
+// projectFileCheck - What validating one project file found.
+type projectFileCheck struct {
+	path     string
+	mode     os.FileMode
+	comments bool
+	doc      yaml.MapSlice
+	fixes    []projectFileFix
+	errors   []string
+}
+
+// fixed - Note a problem, and the change made to doc to correct it.
+func (check *projectFileCheck) fixed(problem, change string) {
+	check.fixes = append(check.fixes, projectFileFix{problem, change})
+}
+
+// Validate - Check project files, or those in directories, for problems.
+// Without fix, every problem is reported. With it, those that can be
+// corrected without guessing are, each change is reported, and the files
+// are written back once confirmed. Anything left is an error either way.
+func Validate(paths []string, fix, yes bool) {
+
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var checks []*projectFileCheck
+	fixable, unfixable := 0, 0
+
+	for _, path := range paths {
+		check := checkProjectFile(path)
+		checks = append(checks, check)
+
+		for _, f := range check.fixes {
+			if fix {
+				cliOut(fmt.Sprintf("%s: %s", check.path, f.change))
+			} else {
+				cliWarn(fmt.Sprintf("%s: %s (fixable with --fix)", check.path, f.problem))
+			}
+		}
+
+		for _, problem := range check.errors {
+			cliWarn(fmt.Sprintf("%s: %s", check.path, problem))
+		}
+
+		if len(check.fixes) > 0 {
+			fixable++
+		}
+		unfixable += len(check.errors)
+	}
+
+	if fix && fixable > 0 {
+		writeFixes(checks, fixable, yes)
+	}
+
+	switch {
+	case unfixable > 0:
+		cliError(fmt.Errorf("Validation failed, %d problem(s) need fixing by hand.", unfixable))
+	case fixable > 0 && !fix:
+		cliError(fmt.Errorf("Validation failed, run with --fix to correct %d file(s).", fixable))
+	case fixable == 0:
+		cliSuccessOut(fmt.Sprintf("%d project file(s) are valid.", len(checks)))
+	}
+}
+
+// writeFixes - Write back each file with fixes, once confirmed. Their
+// comments can't be kept, so that's warned about first.
+func writeFixes(checks []*projectFileCheck, fixable int, yes bool) {
+
+	for _, check := range checks {
+		if len(check.fixes) > 0 && check.comments {
+			cliWarn(check.path + " has comments, which won't be kept.")
+		}
+	}
+
+	if !yes && !confirm(fmt.Sprintf("Write the fixes to %d file(s)?", fixable)) {
+		cliError(errors.New("Nothing written."))
+	}
+
+	for _, check := range checks {
+		if len(check.fixes) == 0 {
+			continue
+		}
+
+		data, err := yaml.Marshal(check.doc)
+
+		if err != nil {
+			cliError(err)
+		}
+
+		if err := ioutil.WriteFile(check.path, data, check.mode); err != nil {
+			cliError(err)
+		}
+	}
+
+	cliSuccessOut(fmt.Sprintf("Fixed %d file(s).", fixable))
+}
+
+// checkProjectFile - Check the project file at path, or in the directory
+// at path, working out the fixes for it in its doc.
+func checkProjectFile(path string) *projectFileCheck {
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path, _ = findProjectFile(path)
+	}
+
+	check := &projectFileCheck{path: path, mode: 0644}
+
+	info, err := os.Stat(path)
+
+	if err != nil {
+		check.errors = append(check.errors, err.Error())
+		return check
+	}
+
+	check.mode = info.Mode().Perm()
+
+	data, err := readProjectData(path)
+
+	if err == nil {
+		err = yaml.Unmarshal(data, &check.doc)
+	}
+
+	if err != nil {
+		check.errors = append(check.errors, err.Error())
+		return check
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			check.comments = true
+		}
+	}
+
+	// With its includes, for the problems that depend on them.
+	project, err := readProjectFile(path)
+
+	if err != nil {
+		check.errors = append(check.errors, err.Error())
+		return check
+	}
+
+	check.fixUnknownFields()
+	check.fixCommands()
+
+	if project.Host == "" {
+		check.fixPath(filepath.Dir(path))
+	}
+
+	if project.ID == "" && project.Name != "" {
+		id := uuid.NewV4().String()
+		check.doc = setField(check.doc, "id", id)
+		check.fixed("There's no id.", "Added the id "+id)
+	}
+
+	if project.Name == "" {
+		check.errors = append(check.errors, "There's no name.")
+	}
+
+	if project.Path == "" {
+		check.errors = append(check.errors, "There's no path.")
+	}
+
+	switch project.Mode {
+	case "", "shell", "exec":
+	default:
+		check.errors = append(check.errors, fmt.Sprintf("Unknown mode %q, expected shell or exec.", project.Mode))
+	}
+
+	if err := project.Limits.validate(); err != nil {
+		check.errors = append(check.errors, err.Error())
+	}
+
+	if err := validateCategory(project.Category); err != nil {
+		check.errors = append(check.errors, err.Error())
+	}
+
+	if _, err := compileColorRules(project.ColorRules); err != nil {
+		check.errors = append(check.errors, err.Error())
+	}
+
+	return check
+}
+
+// fixUnknownFields - Remove fields proj doesn't know, such as misspelt or
+// retired ones, which are otherwise silently ignored.
+func (check *projectFileCheck) fixUnknownFields() {
+
+	known := projectFileFields()
+	kept := check.doc[:0]
+
+	for _, item := range check.doc {
+		name := fmt.Sprint(item.Key)
+
+		if !known[name] {
+			check.fixed("Unknown field "+name, "Removed the unknown field "+name)
+			continue
+		}
+
+		kept = append(kept, item)
+	}
+
+	check.doc = kept
+}
+
+// fixCommands - Trim whitespace around single line commands and scripts.
+// Multi-line ones are left as written.
+func (check *projectFileCheck) fixCommands() {
+
+	trim := func(field string, value interface{}) interface{} {
+		command, ok := value.(string)
+		trimmed := strings.TrimSpace(command)
+
+		if !ok || trimmed == command || strings.Contains(trimmed, "\n") {
+			return value
+		}
+
+		check.fixed("Whitespace around "+field, "Trimmed whitespace around "+field)
+		return trimmed
+	}
+
+	for i, item := range check.doc {
+		name := fmt.Sprint(item.Key)
+
+		switch value := item.Value.(type) {
+		case string:
+			for _, field := range commandFields {
+				if name == field {
+					check.doc[i].Value = trim(name, value)
+				}
+			}
+		case []interface{}:
+			if name == "commands" {
+				for j, command := range value {
+					value[j] = trim(fmt.Sprintf("commands[%d]", j), command)
+				}
+			}
+		case yaml.MapSlice:
+			if name == "scripts" {
+				for j, script := range value {
+					value[j].Value = trim(fmt.Sprintf("script %v", script.Key), script.Value)
+				}
+			}
+		}
+	}
+}
+
+// fixPath - Make a relative path absolute, against dir, where the file is,
+// as commit would otherwise store it relative to wherever it's run from.
+// Paths with variables are left alone, as they may expand to absolute ones.
+func (check *projectFileCheck) fixPath(dir string) {
+
+	for i, item := range check.doc {
+		path, ok := item.Value.(string)
+
+		if fmt.Sprint(item.Key) != "path" || !ok || path == "" || filepath.IsAbs(path) || strings.Contains(path, "$") {
+			continue
+		}
+
+		abs, err := filepath.Abs(filepath.Join(dir, path))
+
+		if err != nil {
+			check.errors = append(check.errors, err.Error())
+			return
+		}
+
+		check.doc[i].Value = abs
+		check.fixed("Relative path "+path, "Made the path absolute, "+abs)
+	}
+}
+
+// projectFileFields - The top level fields a project file can have.
+func projectFileFields() map[string]bool {
+
+	fields := map[string]bool{"include": true}
+	kind := reflect.TypeOf(Project{})
+
+	for i := 0; i < kind.NumField(); i++ {
+		name := strings.Split(kind.Field(i).Tag.Get("yaml"), ",")[0]
+
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+
+	return fields
+}
+
+// setField - doc with the field set to value, added at the top if it's
+// not already there.
+func setField(doc yaml.MapSlice, field string, value interface{}) yaml.MapSlice {
+
+	for i, item := range doc {
+		if fmt.Sprint(item.Key) == field {
+			doc[i].Value = value
+			return doc
+		}
+	}
+
+	return append(yaml.MapSlice{{Key: field, Value: value}}, doc...)
+}
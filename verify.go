@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Shell builtins and keywords, which won't be found on PATH.
+var shellBuiltins = map[string]bool{
+	"cd": true, "echo": true, "export": true, "exec": true, "exit": true,
+	"set": true, "source": true, ".": true, "test": true, "[": true,
+	"true": true, "false": true, "if": true, "for": true, "while": true,
+	"case": true, "eval": true, "trap": true, "wait": true, "sleep": true,
+	"unset": true, "read": true, "printf": true, "(": true, "{": true,
+	"ulimit": true, "umask": true, "command": true, ":": true,
+}
+
+// VerifyProject - Check a project can start. With a verify command, run it;
+// otherwise check the path, each command's syntax, and that the programs
+// they call are installed.
+func (proj *Proj) VerifyProject(name string) {
+
+	project := proj.LoadProject(name)
+
+	if project.VerifyCommand != "" {
+		if err := proj.runCommand(project, project.VerifyCommand); err != nil {
+			cliError(errors.New("Verification failed: " + err.Error()))
+		}
+
+		cliSuccessOut("Verified " + project.Name)
+		return
+	}
+
+	problems := verifyProject(project)
+
+	for _, problem := range problems {
+		cliWarn(problem)
+	}
+
+	if len(problems) > 0 {
+		cliError(fmt.Errorf("Verification failed, %d problem(s).", len(problems)))
+	}
+
+	cliSuccessOut("Verified " + project.Name)
+}
+
+// verifyProject - Every problem that would stop the project starting.
+func verifyProject(project Project) []string {
+
+	var problems []string
+
+	// Remote paths and programs can't be checked from here.
+	remote := project.Host != ""
+
+	if info, err := os.Stat(project.Dir()); !remote && (err != nil || !info.IsDir()) {
+		problems = append(problems, "Path "+project.Dir()+" isn't a directory.")
+	}
+
+	commands := project.StartCommands()
+	if project.TearDown != "" {
+		commands = append(commands, project.TearDown)
+	}
+
+	env := project.Environ()
+
+	for _, command := range commands {
+		command, err := expandCommand(project, command)
+
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+
+		bin := commandBinary(command)
+
+		if project.Mode == "exec" {
+			words, err := splitWords(command)
+
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+
+			if len(words) > 0 {
+				bin = words[0]
+			}
+		}
+
+		// sh -n parses without running anything. Other shells have no
+		// equivalent, so their commands aren't checked.
+		if project.Mode != "exec" && posixShell(config.shell()) {
+			check := exec.Command(config.shell(), "-n", "-c", command)
+
+			if out, err := check.CombinedOutput(); err != nil {
+				problems = append(problems, fmt.Sprintf("Invalid command %q: %s", command, strings.TrimSpace(string(out))))
+				continue
+			}
+		}
+
+		if remote || bin == "" || shellBuiltins[bin] {
+			continue
+		}
+
+		if _, err := lookPath(bin, project.Dir(), getEnv(env, "PATH")); err != nil {
+			problems = append(problems, fmt.Sprintf("%s, needed by %q, wasn't found.", bin, command))
+		}
+	}
+
+	return problems
+}
+
+// commandBinary - The program a shell command runs first, skipping any
+// leading VAR=value assignments.
+func commandBinary(command string) string {
+
+	for _, field := range strings.Fields(command) {
+		if strings.Contains(field, "=") && !strings.HasPrefix(field, "=") {
+			continue
+		}
+
+		return strings.TrimRight(field, ";&|")
+	}
+
+	return ""
+}
+
+// lookPath - Find bin the way the shell would with the given PATH. Names
+// containing a slash are resolved against dir instead.
+func lookPath(bin, dir, path string) (string, error) {
+
+	if strings.Contains(bin, "/") {
+		if !filepath.IsAbs(bin) {
+			bin = filepath.Join(dir, bin)
+		}
+
+		return bin, executable(bin)
+	}
+
+	for _, entry := range filepath.SplitList(path) {
+		if entry == "" {
+			entry = "."
+		}
+
+		candidate := filepath.Join(entry, bin)
+
+		if executable(candidate) == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", errors.New(bin + " not found")
+}
+
+// executable - Check file exists and can be executed.
+func executable(file string) error {
+
+	info, err := os.Stat(file)
+
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return errors.New(file + " isn't executable")
+	}
+
+	return nil
+}
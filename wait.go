@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// How long to wait for endpoints, unless told otherwise.
+const defaultWaitTimeout = 30 * time.Second
+
+// waitFor - Block until every host:port endpoint accepts a TCP connection,
+// giving up once timeout has passed.
+func waitFor(addrs []string, timeout time.Duration) {
+
+	deadline := time.Now().Add(timeout)
+
+	for _, addr := range addrs {
+		cliOut("Waiting for " + addr)
+
+		for {
+			conn, err := net.DialTimeout("tcp", addr, time.Second)
+
+			if err == nil {
+				conn.Close()
+				break
+			}
+
+			if time.Now().After(deadline) {
+				cliError(fmt.Errorf("Timed out after %s waiting for %s", timeout, addr))
+			}
+
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+}
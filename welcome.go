@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+)
+
+// Welcome SQL statements
+var (
+	countProjects = `
+        SELECT COUNT(*) FROM projects
+    `
+)
+
+// welcome - What a new user sees instead of an empty list or an unknown
+// project, with where to start.
+var welcome = []string{
+	"Welcome to proj! There are no projects yet. Add one with:",
+	"  proj init --name my-project --path ~/code/my-project --command \"make run\"",
+	"or bring in existing ones with proj import. See proj --help for more.",
+}
+
+// welcomeCommands - Commands with nothing to do until a project exists,
+// and whether they succeed after the welcome. An empty list isn't an
+// error, but the rest can't do what they were asked to.
+func welcomeCommands() map[string]bool {
+	return map[string]bool{
+		list.FullCommand():    true,
+		status.FullCommand():  true,
+		start.FullCommand():   false,
+		stop.FullCommand():    false,
+		last.FullCommand():    false,
+		show.FullCommand():    false,
+		do.FullCommand():      false,
+		execAll.FullCommand(): false,
+	}
+}
+
+// Welcomed - Greet a new user, if command needs projects and there aren't
+// any yet, returning whether it did, so the command isn't run. JSON output
+// is left alone, as a tool is reading it.
+func (proj *Proj) Welcomed(command string) bool {
+
+	succeeds, ok := welcomeCommands()[command]
+
+	if !ok || *output == "json" {
+		return false
+	}
+
+	var count int
+
+	if err := proj.db.QueryRow(countProjects).Scan(&count); err != nil || count > 0 {
+		return false
+	}
+
+	for _, line := range welcome {
+		cliOut(line)
+	}
+
+	if !succeeds {
+		cliError(errors.New("No projects to work with yet."))
+	}
+
+	return true
+}
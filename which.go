@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ExecutionPlan - How a project's commands would be run.
+type ExecutionPlan struct {
+	Commands []string          `json:"commands"`
+	Shell    []string          `json:"shell"`
+	Host     string            `json:"host,omitempty"`
+	Dir      string            `json:"dir"`
+	Env      map[string]string `json:"env,omitempty"`
+	CleanEnv bool              `json:"clean_env"`
+}
+
+// Which - Print the execution plan for a project's start (or tear down) command.
+func (proj *Proj) Which(name string, teardown bool) {
+
+	project, _, err := proj.LoadProject(name).withEnvLayers("", nil)
+
+	if err != nil {
+		cliError(err)
+	}
+
+	commands := project.StartCommands()
+	if teardown {
+		commands = []string{project.TearDown}
+	}
+
+	for i, command := range commands {
+		expanded, err := expandCommand(project, command)
+
+		if err != nil {
+			cliError(err)
+		}
+
+		commands[i] = expanded
+	}
+
+	// Exec mode commands are shown as the words they're split into, quoted
+	// so they can still be pasted into a shell.
+	if project.Mode == "exec" {
+		for i, command := range commands {
+			words, err := splitWords(command)
+
+			if err != nil {
+				cliError(err)
+			}
+
+			commands[i] = quoteWords(words)
+		}
+	}
+
+	// Everything but the command itself is the same for each one. The env
+	// comes from the project, so its secrets needn't be looked up.
+	bare := project
+	bare.Env = nil
+	bare.Mode = ""
+	cmd := projectCommand(bare, "")
+
+	shell := cmd.Args[:len(cmd.Args)-1]
+	if project.Mode == "exec" {
+		shell = nil
+	}
+
+	plan := ExecutionPlan{
+		Commands: commands,
+		Shell:    shell,
+		Host:     project.Host,
+		Dir:      cmd.Dir,
+		Env:      envOverrides(project.Environ()),
+		CleanEnv: project.CleanEnv,
+	}
+
+	// Remote commands get the project's env and path on the remote side.
+	if project.Host != "" {
+		plan.Dir = project.Path
+		plan.Env = project.Env
+	}
+
+	separator := " && "
+	if project.ContinueOnError && !teardown {
+		separator = "; "
+	}
+
+	if *output == "json" {
+		cliJSON(plan)
+		return
+	}
+
+	for _, command := range plan.Commands {
+		cliOut("Command: " + command)
+	}
+
+	if plan.Host != "" {
+		cliOut("Host: " + plan.Host)
+	}
+
+	cliOut("Directory: " + plan.Dir)
+
+	if plan.CleanEnv {
+		cliOut("Environment: clean")
+	}
+
+	keys := make([]string, 0, len(plan.Env))
+	for key := range plan.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		cliOut(fmt.Sprintf("Env: %s=%s", key, plan.Env[key]))
+	}
+
+	// A line that can be pasted straight into a shell.
+	if plan.Host != "" {
+		remote := remoteCommand(project, strings.Join(plan.Commands, separator))
+		fmt.Printf("ssh %s %s\n", plan.Host, shellQuote(remote.Args[2]))
+		return
+	}
+
+	fmt.Printf("cd %s && %s\n", shellQuote(plan.Dir), strings.Join(plan.Commands, separator))
+}
+
+// envOverrides - Variables in env that differ from proj's own environment.
+func envOverrides(env []string) map[string]string {
+
+	overrides := map[string]string{}
+
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+
+		if value, ok := os.LookupEnv(parts[0]); !ok || value != parts[1] {
+			overrides[parts[0]] = parts[1]
+		}
+	}
+
+	return overrides
+}
+
+// shellQuote - Quote s for use as a single sh word.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Width assumed when the terminal's can't be found.
+const defaultWidth = 80
+
+// terminalWidth - How many columns stdout's terminal has, looked up each
+// time so a resized terminal is picked up. Falls back to $COLUMNS, then 80.
+func terminalWidth() int {
+
+	if width, _, err := term.GetSize(int(terminalOut().Fd())); err == nil && width > 0 {
+		return width
+	}
+
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+
+	return defaultWidth
+}
+
+// newTable - An aligned table writer for stdout. On a terminal, rows wider
+// than it are cut short rather than wrapping; piped output is left whole.
+func newTable() *tabwriter.Writer {
+
+	var out io.Writer = os.Stdout
+
+	if term.IsTerminal(int(terminalOut().Fd())) {
+		out = &fitWriter{out: os.Stdout, width: terminalWidth()}
+	}
+
+	return tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+}
+
+// terminalOut - Where stdout ends up: the terminal behind the pager, if
+// output is being paged.
+func terminalOut() *os.File {
+
+	if pagedTerminal != nil {
+		return pagedTerminal
+	}
+
+	return os.Stdout
+}
+
+// fitWriter - Cuts each line written to it down to width, marking where
+// it's been cut with an ellipsis.
+type fitWriter struct {
+	out     io.Writer
+	width   int
+	partial []byte
+}
+
+// Write - Write whole lines, fitted to the width, holding back any partial
+// one until it's finished.
+func (w *fitWriter) Write(p []byte) (int, error) {
+
+	w.partial = append(w.partial, p...)
+
+	for {
+		end := strings.IndexByte(string(w.partial), '\n')
+
+		if end < 0 {
+			return len(p), nil
+		}
+
+		line := fitLine(string(w.partial[:end]), w.width)
+		w.partial = w.partial[end+1:]
+
+		if _, err := io.WriteString(w.out, line+"\n"); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// fitLine - line, cut to width characters with an ellipsis if it's longer.
+func fitLine(line string, width int) string {
+
+	if utf8.RuneCountInString(line) <= width || width < 1 {
+		return line
+	}
+
+	runes := []rune(line)
+
+	return string(runes[:width-1]) + "…"
+}